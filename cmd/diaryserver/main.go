@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
+	"diaryserver/internal/backup"
 	"diaryserver/internal/config"
 	"diaryserver/internal/router"
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/backend"
+	"diaryserver/internal/storage/schema"
+	"diaryserver/internal/trash"
 	"log/slog"
 	"os"
 	"path/filepath"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
@@ -25,6 +28,8 @@ func main() {
 	log.Debug("debug messages are enabled")
 
 	storage := InitStorage(cfg, log)
+	InitBackups(storage, cfg, log)
+	InitTrashPurge(storage, cfg, log)
 	//init router
 	router := router.SetupRouter(storage, log, cfg)
 	//run server
@@ -55,17 +60,42 @@ func SetupLogger(env string) *slog.Logger {
 	}
 	return log
 }
-func InitStorage(cfg *config.Config, log *slog.Logger) *sqlite.Storage {
-	absolutePath, err := filepath.Abs(cfg.StoragePath)
-	if err != nil {
-		log.Debug("error in getting absolute path", "error", err)
-	} else {
-		log.Debug(cfg.StoragePath, "absolute path", absolutePath)
+func InitStorage(cfg *config.Config, log *slog.Logger) storage.Storage {
+	if cfg.Storage.Driver == config.StorageDriverSQLite {
+		if absolutePath, err := filepath.Abs(cfg.StoragePath); err != nil {
+			log.Debug("error in getting absolute path", "error", err)
+		} else {
+			log.Debug(cfg.StoragePath, "absolute path", absolutePath)
+		}
 	}
-	storage, err := sqlite.New(cfg.StoragePath)
+	store, err := backend.Open(cfg)
 	if err != nil {
-		log.Error("failed to init storage", "error", err)
+		log.Error("failed to init storage", "driver", cfg.Storage.Driver, "error", err)
+		os.Exit(1)
+	}
+	if err := schema.Ensure(cfg, log); err != nil {
+		log.Error("storage schema is not usable", "driver", cfg.Storage.Driver, "error", err)
 		os.Exit(1)
 	}
-	return storage
+	return store
+}
+
+func InitBackups(store storage.Storage, cfg *config.Config, log *slog.Logger) {
+	if cfg.Backup.Interval <= 0 {
+		return
+	}
+	src, ok := store.(backup.Source)
+	if !ok {
+		log.Warn("scheduled backups are not supported for this driver", "driver", cfg.Storage.Driver)
+		return
+	}
+	log.Info("scheduled backups enabled", slog.String("dir", cfg.Backup.Dir), slog.Duration("interval", cfg.Backup.Interval))
+	go backup.NewScheduler(src, cfg.Backup, cfg.PicturesPath, log).Run(context.Background())
+}
+
+func InitTrashPurge(store storage.Storage, cfg *config.Config, log *slog.Logger) {
+	if cfg.Trash.PurgeInterval <= 0 {
+		return
+	}
+	go trash.NewPurger(store, cfg.Trash, cfg.PicturesPath, log).Run(context.Background())
 }
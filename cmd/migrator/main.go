@@ -1,48 +1,271 @@
 package main
 
 import (
+	"diaryserver/internal/config"
+	"diaryserver/internal/storage/schema"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	// Библиотека для миграций
 	"github.com/golang-migrate/migrate/v4"
-	// Драйвер для выполнения миграций SQLite 3
-	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
 	// Драйвер для получения миграций из файлов
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/file"
 )
 
+const usage = `usage: migrator [flags] <command> [args]
+
+commands:
+  up [N]        apply all pending migrations, or the next N
+  down [N]      roll back the last N migrations (default 1)
+  goto V        migrate up or down to version V
+  version       print the current schema version
+  force V       set the version to V, or -1 for none, without running anything,
+                clearing the dirty flag
+  create NAME   write the next numbered NAME.up.sql and NAME.down.sql files
+  status        list migrations and whether they are applied
+
+flags:
+`
+
 func main() {
-	var storagePath, migrationsPath string
+	var configPath, driver, storagePath, dsn, migrationsPath string
 
-	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
-	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations")
+	flag.StringVar(&configPath, "config", os.Getenv("CONFIG_PATH"), "path to the server config file")
+	flag.StringVar(&driver, "driver", "", "storage driver: sqlite or postgres (overrides config)")
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage, sqlite (overrides config)")
+	flag.StringVar(&dsn, "dsn", "", "connection string, postgres (overrides config)")
+	flag.StringVar(&migrationsPath, "migrations-path", "", "read migrations from this directory instead of the embedded ones")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if storagePath == "" {
-		panic("storage-path is required")
+	args := flag.Args()
+	if len(args) == 0 {
+		// Без команды ведём себя как раньше: применяем все миграции.
+		args = []string{"up"}
 	}
-	if migrationsPath == "" {
-		panic("migrations-path is required")
+
+	cfg := &config.Config{}
+	if configPath != "" {
+		cfg = config.MustLoadPath(configPath)
+	}
+	if driver != "" {
+		cfg.Storage.Driver = driver
+	}
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = config.StorageDriverSQLite
+	}
+	if storagePath != "" {
+		cfg.StoragePath = storagePath
+	}
+	if dsn != "" {
+		cfg.Storage.DSN = dsn
+	}
+
+	command, args := args[0], args[1:]
+	if command == "create" {
+		if len(args) != 1 {
+			exitUsage("create needs a migration name")
+		}
+		if err := create(migrationsDir(cfg, migrationsPath), args[0]); err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		return
 	}
 
-	m, err := migrate.New(
-		"file://"+migrationsPath,
-		fmt.Sprintf("sqlite3://%s", storagePath),
-	)
+	if err := cfg.ValidateStorage(); err != nil {
+		log.Fatal(err)
+	}
+	src, err := openSource(cfg, migrationsPath)
+	if err != nil {
+		log.Fatalf("Failed to open migrations: %v", err)
+	}
+	m, err := schema.NewWithSource(cfg, src)
 	if err != nil {
 		log.Fatalf("Failed to initialize migrations: %v", err)
-		panic(err)
 	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		if len(args) == 0 {
+			err = m.Up()
+		} else {
+			err = m.Steps(mustCount(args))
+		}
+	case "down":
+		n := 1
+		if len(args) > 0 {
+			n = mustCount(args)
+		}
+		err = m.Steps(-n)
+	case "goto":
+		err = m.Migrate(uint(mustVersion(args, 0)))
+	case "force":
+		err = m.Force(mustVersion(args, -1))
+	case "version":
+		err = printVersion(m)
+	case "status":
+		err = printStatus(m, cfg, migrationsPath)
+	default:
+		exitUsage(fmt.Sprintf("unknown command %q", command))
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no migrations to apply")
+		return
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", command, err)
+	}
+	if command != "version" && command != "status" {
+		if err := printVersion(m); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func exitUsage(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	flag.Usage()
+	os.Exit(2)
+}
+
+func mustCount(args []string) int {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		exitUsage("N must be a positive integer")
+	}
+	return n
+}
+
+// mustVersion parses the version argument, which may not be below min: -1
+// for force, to mark the schema as having no version, and 0 otherwise.
+func mustVersion(args []string, min int) int {
+	if len(args) == 0 {
+		exitUsage("a version is required")
+	}
+	v, err := strconv.Atoi(args[0])
+	if err != nil || v < min {
+		exitUsage("V must be a migration version")
+	}
+	return v
+}
+
+func migrationsDir(cfg *config.Config, migrationsPath string) string {
+	if migrationsPath != "" {
+		return migrationsPath
+	}
+	if cfg.Storage.Driver == config.StorageDriverPostgres {
+		return filepath.Join("migrations", "postgres")
+	}
+	return "migrations"
+}
+
+func openSource(cfg *config.Config, migrationsPath string) (source.Driver, error) {
+	if migrationsPath == "" {
+		return schema.Source(cfg.Storage.Driver)
+	}
+	return (&file.File{}).Open("file://" + migrationsPath)
+}
+
+func printVersion(m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("no migrations applied")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Printf("version %d (dirty)\n", version)
+		return nil
+	}
+	fmt.Printf("version %d\n", version)
+	return nil
+}
+
+// printStatus lists every known migration. golang-migrate applies migrations
+// in order, so everything up to the current version is applied.
+func printStatus(m *migrate.Migrate, cfg *config.Config, migrationsPath string) error {
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	src, err := openSource(cfg, migrationsPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			fmt.Println("no migrations to apply")
+	version, err := src.First()
+	for err == nil {
+		r, identifier, readErr := src.ReadUp(version)
+		if readErr != nil {
+			return readErr
+		}
+		r.Close()
+		state := "pending"
+		switch {
+		case version == current && dirty:
+			state = "dirty"
+		case version <= current:
+			state = "applied"
+		}
+		fmt.Printf("%4d  %-8s %s\n", version, state, identifier)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
 
-			return
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// create writes an empty up/down pair numbered after the highest existing
+// migration in dir.
+func create(dir, name string) error {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return errors.New("migration name must contain letters or digits")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var next uint = 1
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m, err := source.DefaultParse(e.Name())
+		if err != nil {
+			continue
+		}
+		if m.Version >= next {
+			next = m.Version + 1
+		}
+	}
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%d_%s.%s.sql", next, name, direction))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
 		}
-		panic(err)
+		f.Close()
+		fmt.Println("created", path)
 	}
+	return nil
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"diaryserver/internal/config"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage/backend"
+	"diaryserver/internal/storage/schema"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+)
+
+const usage = `usage: rekey [flags]
+
+Rewraps every user's data key with a new master key and encrypts the fields
+still stored as plain text or in the format of older builds. The current master key comes from the
+config or DIARY_MASTER_KEY and may be empty the first time encryption is
+turned on. The new key is read from DIARY_NEW_MASTER_KEY, or generated and
+printed with -generate. Stop the server first and restart it with the new key.
+
+flags:
+`
+
+func main() {
+	var configPath, driver, storagePath, dsn string
+	var generate bool
+
+	flag.StringVar(&configPath, "config", os.Getenv("CONFIG_PATH"), "path to the server config file")
+	flag.StringVar(&driver, "driver", "", "storage driver: sqlite or postgres (overrides config)")
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage, sqlite (overrides config)")
+	flag.StringVar(&dsn, "dsn", "", "connection string, postgres (overrides config)")
+	flag.BoolVar(&generate, "generate", false, "generate the new master key and print it")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg := &config.Config{}
+	if configPath != "" {
+		cfg = config.MustLoadPath(configPath)
+	}
+	if driver != "" {
+		cfg.Storage.Driver = driver
+	}
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = config.StorageDriverSQLite
+	}
+	if storagePath != "" {
+		cfg.StoragePath = storagePath
+	}
+	if dsn != "" {
+		cfg.Storage.DSN = dsn
+	}
+	if cfg.Encryption.MasterKey == "" {
+		cfg.Encryption.MasterKey = os.Getenv("DIARY_MASTER_KEY")
+	}
+	if err := cfg.ValidateStorage(); err != nil {
+		log.Fatal(err)
+	}
+
+	encoded := os.Getenv("DIARY_NEW_MASTER_KEY")
+	if generate {
+		var err error
+		if encoded, err = encryption.GenerateKey(); err != nil {
+			log.Fatalf("Failed to generate master key: %v", err)
+		}
+	}
+	if encoded == "" {
+		log.Fatal("no new master key: set DIARY_NEW_MASTER_KEY or pass -generate")
+	}
+	next, err := encryption.ParseKey(encoded)
+	if err != nil {
+		log.Fatalf("Invalid new master key: %v", err)
+	}
+
+	if err := schema.Ensure(cfg, slog.New(slog.NewTextHandler(os.Stderr, nil))); err != nil {
+		log.Fatalf("Schema is not ready: %v", err)
+	}
+	store, err := backend.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+
+	rekeyer, ok := store.(encryption.Rekeyer)
+	if !ok {
+		log.Fatalf("rekey is not supported for the %s driver", cfg.Storage.Driver)
+	}
+	result, err := rekeyer.Rekey(context.Background(), next)
+	if err != nil {
+		log.Fatalf("Failed to rekey: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "rewrapped %d data keys, encrypted the fields of %d rows\n", result.Rewrapped, result.Sealed)
+	if generate {
+		fmt.Println(encoded)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"diaryserver/internal/backup"
+	"diaryserver/internal/config"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const usage = `usage: restore [flags] <snapshot | latest>
+
+Replaces the sqlite database, and the pictures directory if the snapshot
+includes it, with a snapshot taken by the server. Stop the server first.
+The replaced files are kept next to the originals.
+
+flags:
+`
+
+func main() {
+	var configPath, storagePath, picturesPath, backupDir string
+	var list bool
+
+	flag.StringVar(&configPath, "config", os.Getenv("CONFIG_PATH"), "path to the server config file")
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage, sqlite (overrides config)")
+	flag.StringVar(&picturesPath, "pictures-path", "", "pictures directory (overrides config)")
+	flag.StringVar(&backupDir, "backup-dir", "", "directory with snapshots (overrides config)")
+	flag.BoolVar(&list, "list", false, "list the snapshots in the backup directory and exit")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg := &config.Config{}
+	if configPath != "" {
+		cfg = config.MustLoadPath(configPath)
+	}
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = config.StorageDriverSQLite
+	}
+	if storagePath != "" {
+		cfg.StoragePath = storagePath
+	}
+	if picturesPath != "" {
+		cfg.PicturesPath = picturesPath
+	}
+	if backupDir != "" {
+		cfg.Backup.Dir = backupDir
+	}
+
+	if list {
+		snapshots, err := backup.List(cfg.Backup.Dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range snapshots {
+			fmt.Println(s)
+		}
+		return
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := cfg.ValidateStorage(); err != nil {
+		log.Fatal(err)
+	}
+
+	snapshot := flag.Arg(0)
+	if snapshot == "latest" {
+		snapshots, err := backup.List(cfg.Backup.Dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(snapshots) == 0 {
+			log.Fatalf("no snapshots in %s", cfg.Backup.Dir)
+		}
+		snapshot = snapshots[len(snapshots)-1]
+	}
+
+	restored, err := backup.Restore(cfg, snapshot)
+	if err != nil {
+		log.Fatalf("Failed to restore: %v", err)
+	}
+	fmt.Printf("restored %s (schema version %d)\n", snapshot, restored.Version)
+	if restored.PreviousDB != "" {
+		fmt.Println("previous database kept at", restored.PreviousDB)
+	}
+	if restored.PreviousPictures != "" {
+		fmt.Println("previous pictures kept at", restored.PreviousPictures)
+	}
+	if restored.Version < restored.Expected {
+		fmt.Printf("the snapshot predates schema version %d; run the migrator or start the server with storage.auto_migrate\n", restored.Expected)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"diaryserver/internal/config"
+	"diaryserver/internal/seed"
+	"diaryserver/internal/storage/backend"
+	"diaryserver/internal/storage/schema"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	var configPath, driver, storagePath, dsn string
+	var timeout time.Duration
+
+	flag.StringVar(&configPath, "config", os.Getenv("CONFIG_PATH"), "path to the server config file")
+	flag.StringVar(&driver, "driver", "", "storage driver: sqlite or postgres (overrides config)")
+	flag.StringVar(&storagePath, "storage-path", "", "path to storage, sqlite (overrides config)")
+	flag.StringVar(&dsn, "dsn", "", "connection string, postgres (overrides config)")
+	flag.DurationVar(&timeout, "query-timeout", 0, "timeout for a single query (overrides config)")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "usage: seed [flags] <dataset>...\n\ndatasets: %s\n\nflags:\n", strings.Join(seed.Names(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := &config.Config{}
+	if configPath != "" {
+		cfg = config.MustLoadPath(configPath)
+	}
+	if driver != "" {
+		cfg.Storage.Driver = driver
+	}
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = config.StorageDriverSQLite
+	}
+	if storagePath != "" {
+		cfg.StoragePath = storagePath
+	}
+	if dsn != "" {
+		cfg.Storage.DSN = dsn
+	}
+	if timeout != 0 {
+		cfg.Storage.QueryTimeout = timeout
+	}
+	if cfg.Encryption.MasterKey == "" {
+		cfg.Encryption.MasterKey = os.Getenv("DIARY_MASTER_KEY")
+	}
+	if err := cfg.ValidateStorage(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Seeding goes through the storage layer, so the schema must be current.
+	if err := schema.Ensure(cfg, slog.New(slog.NewTextHandler(os.Stderr, nil))); err != nil {
+		log.Fatalf("Schema is not ready: %v", err)
+	}
+
+	store, err := backend.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+
+	for _, name := range flag.Args() {
+		if err := seed.Apply(context.Background(), store, name); err != nil {
+			log.Fatalf("Failed to seed: %v", err)
+		}
+		fmt.Println("seeded", name)
+	}
+}
@@ -0,0 +1,189 @@
+// Package backup takes consistent snapshots of the sqlite database while the
+// server runs and restores them.
+//
+// A snapshot is either a plain database file, diary-<time>.db, or, when
+// pictures are included, a gzipped tarball diary-<time>.tar.gz holding
+// storage.db and a pictures/ directory.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"diaryserver/internal/config"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotPrefix = "diary-"
+	timeLayout     = "20060102T150405Z"
+	extDB          = ".db"
+	extTarball     = ".tar.gz"
+
+	// Names of the entries inside a tarball.
+	tarDB       = "storage.db"
+	tarPictures = "pictures"
+)
+
+// Source is a database that can copy itself to a file.
+type Source interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// Snapshot writes a new snapshot of src into cfg.Dir and returns its path.
+// picturesPath is only read when cfg.IncludePictures is set.
+// Files are written under a temporary name and renamed once complete, so a
+// snapshot that is listed is never partial.
+func Snapshot(ctx context.Context, src Source, cfg config.Backup, picturesPath string) (string, error) {
+	const op = "backup.Snapshot"
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	name := snapshotPrefix + time.Now().UTC().Format(timeLayout)
+
+	dbPath := filepath.Join(cfg.Dir, name+extDB)
+	tmpDB := dbPath + ".tmp"
+	if err := src.Backup(ctx, tmpDB); err != nil {
+		os.Remove(tmpDB)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !cfg.IncludePictures {
+		if err := publish(tmpDB, dbPath); err != nil {
+			os.Remove(tmpDB)
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		return dbPath, nil
+	}
+
+	defer os.Remove(tmpDB)
+	tarPath := filepath.Join(cfg.Dir, name+extTarball)
+	tmpTar := tarPath + ".tmp"
+	if err := writeTarball(tmpTar, tmpDB, picturesPath); err != nil {
+		os.Remove(tmpTar)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := publish(tmpTar, tarPath); err != nil {
+		os.Remove(tmpTar)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return tarPath, nil
+}
+
+// publish renames a finished temporary file to its snapshot name, refusing to
+// replace a snapshot taken within the same second.
+func publish(tmp, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("snapshot %s already exists", path)
+	}
+	return os.Rename(tmp, path)
+}
+
+// List returns the complete snapshots in dir, oldest first.
+func List(dir string) ([]string, error) {
+	const op = "backup.List"
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var snapshots []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, snapshotPrefix) {
+			continue
+		}
+		if strings.HasSuffix(name, extDB) || strings.HasSuffix(name, extTarball) {
+			snapshots = append(snapshots, filepath.Join(dir, name))
+		}
+	}
+	// The timestamp in the name sorts chronologically.
+	sort.Strings(snapshots)
+	return snapshots, nil
+}
+
+// Prune deletes all but the keep newest snapshots in dir. A keep of zero or
+// less keeps everything.
+func Prune(dir string, keep int) error {
+	const op = "backup.Prune"
+	if keep <= 0 {
+		return nil
+	}
+	snapshots, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for len(snapshots) > keep {
+		if err := os.Remove(snapshots[0]); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+func writeTarball(path, dbPath, picturesPath string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	if err := addFile(tw, dbPath, tarDB); err != nil {
+		return err
+	}
+	if picturesPath != "" {
+		entries, err := os.ReadDir(picturesPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			if err := addFile(tw, filepath.Join(picturesPath, e.Name()), tarPictures+"/"+e.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func addFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,174 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"diaryserver/internal/config"
+	"diaryserver/internal/storage/schema"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Restored describes a finished restore.
+type Restored struct {
+	// Version is the schema version of the snapshot. When it is below
+	// Expected, the migrator has to bring it up to date.
+	Version  uint
+	Expected uint
+	// PreviousDB and PreviousPictures are where the replaced files were moved.
+	// They are empty when there was nothing to replace.
+	PreviousDB       string
+	PreviousPictures string
+}
+
+// Restore replaces the database at cfg.StoragePath, and the pictures
+// directory if the snapshot has one, with the contents of snapshot. The
+// snapshot is unpacked next to the database and its schema version checked
+// before anything is touched; the replaced files are kept with a
+// .before-restore-<time> suffix. The server must not be running.
+func Restore(cfg *config.Config, snapshot string) (*Restored, error) {
+	const op = "backup.Restore"
+	if cfg.Storage.Driver != config.StorageDriverSQLite {
+		return nil, fmt.Errorf("%s: restore is only supported for the sqlite driver", op)
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(cfg.StoragePath), ".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.RemoveAll(staging)
+
+	stagedDB := filepath.Join(staging, tarDB)
+	stagedPictures := filepath.Join(staging, tarPictures)
+	if strings.HasSuffix(snapshot, extTarball) {
+		err = extractTarball(snapshot, staging)
+	} else {
+		err = copyFile(snapshot, stagedDB)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := os.Stat(stagedDB); err != nil {
+		return nil, fmt.Errorf("%s: snapshot has no database: %w", op, err)
+	}
+
+	stagedCfg := *cfg
+	stagedCfg.StoragePath = stagedDB
+	version, expected, err := schema.Check(&stagedCfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: snapshot rejected: %w", op, err)
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%s: snapshot rejected: no migrations applied", op)
+	}
+
+	restored := &Restored{Version: version, Expected: expected}
+	suffix := ".before-restore-" + time.Now().UTC().Format(timeLayout)
+
+	restored.PreviousDB, err = moveAside(cfg.StoragePath, suffix)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Uncheckpointed pages of the old database belong with it, not with the
+	// restored file.
+	for _, ext := range []string{"-wal", "-shm"} {
+		if _, err := moveAside(cfg.StoragePath+ext, suffix); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := os.Rename(stagedDB, cfg.StoragePath); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := os.Stat(stagedPictures); err == nil && cfg.PicturesPath != "" {
+		restored.PreviousPictures, err = moveAside(cfg.PicturesPath, suffix)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := os.Rename(stagedPictures, cfg.PicturesPath); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return restored, nil
+}
+
+// moveAside renames p to p+suffix and returns the new path, or "" if p does
+// not exist.
+func moveAside(p, suffix string) (string, error) {
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return "", nil
+	}
+	if err := os.Rename(p, p+suffix); err != nil {
+		return "", err
+	}
+	return p + suffix, nil
+}
+
+// extractTarball unpacks the database and pictures of a snapshot into dir.
+// Any other entry is rejected so a crafted archive cannot write elsewhere.
+func extractTarball(src, dir string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected entry %q in snapshot", header.Name)
+		}
+		dirName, base := path.Split(header.Name)
+		switch {
+		case header.Name == tarDB:
+		case dirName == tarPictures+"/" && base != "" && base != "." && base != "..":
+			if err := os.MkdirAll(filepath.Join(dir, tarPictures), 0o755); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected entry %q in snapshot", header.Name)
+		}
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(header.Name)), tr); err != nil {
+			return err
+		}
+	}
+}
+
+func copyFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeFile(dst, f)
+}
+
+func writeFile(dst string, r io.Reader) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"context"
+	"diaryserver/internal/config"
+	"log/slog"
+	"time"
+)
+
+type Scheduler struct {
+	src          Source
+	cfg          config.Backup
+	picturesPath string
+	log          *slog.Logger
+}
+
+func NewScheduler(src Source, cfg config.Backup, picturesPath string, log *slog.Logger) *Scheduler {
+	return &Scheduler{src: src, cfg: cfg, picturesPath: picturesPath, log: log}
+}
+
+// Run takes a snapshot every cfg.Interval and prunes old ones until ctx is
+// done. A failed snapshot is logged and retried at the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context) {
+	start := time.Now()
+	path, err := Snapshot(ctx, s.src, s.cfg, s.picturesPath)
+	if err != nil {
+		s.log.Error("backup failed", "error", err)
+		return
+	}
+	s.log.Info("backup written", slog.String("path", path), slog.Duration("took", time.Since(start)))
+	if err := Prune(s.cfg.Dir, s.cfg.Keep); err != nil {
+		s.log.Error("failed to prune backups", "error", err)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -8,12 +10,61 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	StorageDriverSQLite   = "sqlite"
+	StorageDriverPostgres = "postgres"
+)
+
 type Config struct {
-	Env         string `yaml:"env" env-default:"local"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
-	HTTPServer  `yaml:"http_server"`
-	JWT         JWT `yaml:"jwt"`
-	TLS         TLS `yaml:"tls"`
+	Env          string     `yaml:"env" env-default:"local"`
+	StoragePath  string     `yaml:"storage_path"`
+	PicturesPath string     `yaml:"pictures_path" env-default:"./storage/pictures"`
+	Storage      Storage    `yaml:"storage"`
+	Backup       Backup     `yaml:"backup"`
+	Trash        Trash      `yaml:"trash"`
+	Encryption   Encryption `yaml:"encryption"`
+	Admin        Admin      `yaml:"admin"`
+	HTTPServer   `yaml:"http_server"`
+	JWT          JWT `yaml:"jwt"`
+	TLS          TLS `yaml:"tls"`
+}
+
+type Storage struct {
+	Driver       string        `yaml:"driver" env-default:"sqlite"`
+	DSN          string        `yaml:"dsn"`
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"2s"`
+	AutoMigrate  bool          `yaml:"auto_migrate" env-default:"false"`
+}
+
+// Backup configures scheduled snapshots of the sqlite database. Snapshots are
+// disabled while Interval is zero.
+type Backup struct {
+	Dir             string        `yaml:"dir" env-default:"./storage/backups"`
+	Interval        time.Duration `yaml:"interval" env-default:"0"`
+	Keep            int           `yaml:"keep" env-default:"7"`
+	IncludePictures bool          `yaml:"include_pictures" env-default:"false"`
+}
+
+// Trash configures how long soft-deleted workouts are kept before the purge
+// job removes them for good.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Encryption configures encryption at rest of workout notes and photo paths,
+// the notes of templates and recurring workouts, and body weights. Sets stay
+// in plain text, and search matches exercise names only while it is on. It
+// is off while MasterKey is empty. MasterKey is 32 random bytes, base64
+// encoded; set it through DIARY_MASTER_KEY rather than in the config file.
+type Encryption struct {
+	MasterKey string `yaml:"master_key" env:"DIARY_MASTER_KEY"`
+}
+
+// Admin lists the users allowed to manage shared data such as exercise
+// translations.
+type Admin struct {
+	Usernames []string `yaml:"usernames" env:"DIARY_ADMINS" env-separator:","`
 }
 
 type HTTPServer struct {
@@ -40,6 +91,10 @@ func MustLoad() *Config {
 	if configPath == "" {
 		log.Fatal("config path is not set")
 	}
+	return MustLoadPath(configPath)
+}
+
+func MustLoadPath(configPath string) *Config {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		log.Fatalf("config file %s does not exist", configPath)
 	}
@@ -47,6 +102,27 @@ func MustLoad() *Config {
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		log.Fatalf("cannot read config: %s", err)
 	}
+	if err := cfg.ValidateStorage(); err != nil {
+		log.Fatal(err)
+	}
 
 	return &cfg
 }
+
+// ValidateStorage checks that the settings the chosen storage driver needs
+// are present.
+func (cfg *Config) ValidateStorage() error {
+	switch cfg.Storage.Driver {
+	case StorageDriverSQLite:
+		if cfg.StoragePath == "" {
+			return errors.New("storage_path is required for the sqlite driver")
+		}
+	case StorageDriverPostgres:
+		if cfg.Storage.DSN == "" {
+			return errors.New("storage.dsn is required for the postgres driver")
+		}
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+	return nil
+}
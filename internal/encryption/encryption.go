@@ -0,0 +1,272 @@
+// Package encryption seals sensitive fields with AES-GCM envelope encryption.
+// Every user has a random data key that encrypts their fields; data keys are
+// stored wrapped (encrypted) by a master key that never touches the database.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KeySize is the size in bytes of master and data keys (AES-256).
+const KeySize = 32
+
+// sealedPrefix marks a value sealed for a Field. Backends recognise sealed
+// values in SQL by their "enc:v" prefix, so a new format only changes the
+// version after it.
+const sealedPrefix = "enc:v2:"
+
+// legacyPrefix marks a value sealed with only the field name as additional
+// data. Such values still open; a rekey seals them again for their Field.
+const legacyPrefix = "enc:v1:"
+
+var (
+	// ErrNoMasterKey is returned when a sealed value is read while no master
+	// key is configured.
+	ErrNoMasterKey = errors.New("value is encrypted but no master key is configured")
+	// ErrWrongMasterKey is returned when a data key was wrapped by a master
+	// key other than the configured one.
+	ErrWrongMasterKey = errors.New("data key was wrapped by a different master key")
+)
+
+// Key is a master key.
+type Key struct {
+	aead cipher.AEAD
+	id   string
+}
+
+// ParseKey decodes a base64 master key.
+func ParseKey(encoded string) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("master key is %d bytes, want %d", len(raw), KeySize)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &Key{aead: aead, id: hex.EncodeToString(sum[:8])}, nil
+}
+
+// GenerateKey returns a new random master key, base64 encoded.
+func GenerateKey() (string, error) {
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// ID identifies the key without revealing it. It is stored next to every
+// data key the key wraps.
+func (k *Key) ID() string {
+	return k.id
+}
+
+// NewDataKey returns a random data key and the same key wrapped by k.
+func (k *Key) NewDataKey() (dataKey, wrapped []byte, err error) {
+	dataKey = make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err = k.Wrap(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, wrapped, nil
+}
+
+// Wrap encrypts dataKey with k.
+func (k *Key) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, nil)
+}
+
+// Unwrap decrypts a data key wrapped by k.
+func (k *Key) Unwrap(wrapped []byte) ([]byte, error) {
+	dataKey, err := open(k.aead, wrapped, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// Field is what a sealed value belongs to: the name of the field, the user
+// and the row that holds it. It is bound to the value as additional data, so
+// a sealed value copied into another field, row or user's account fails to
+// open.
+type Field struct {
+	Name   string
+	UserID int64
+	RowID  int64
+}
+
+func (f Field) additionalData() []byte {
+	return []byte(f.Name + ":" + strconv.FormatInt(f.UserID, 10) + ":" + strconv.FormatInt(f.RowID, 10))
+}
+
+// IsSealed reports whether a stored value was produced by Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix) || strings.HasPrefix(value, legacyPrefix)
+}
+
+// IsCurrent reports whether a stored value is empty or sealed in the current
+// format, so that a rekey leaves it alone.
+func IsCurrent(value string) bool {
+	return value == "" || strings.HasPrefix(value, sealedPrefix)
+}
+
+// Seal encrypts plaintext with dataKey for field. Empty values are left as
+// they are.
+func Seal(dataKey []byte, field Field, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), field.additionalData())
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed with dataKey for field. Values that are not
+// sealed are returned as they are.
+func Open(dataKey []byte, field Field, value string) (string, error) {
+	var encoded string
+	var additional []byte
+	switch {
+	case strings.HasPrefix(value, sealedPrefix):
+		encoded, additional = strings.TrimPrefix(value, sealedPrefix), field.additionalData()
+	case strings.HasPrefix(value, legacyPrefix):
+		encoded, additional = strings.TrimPrefix(value, legacyPrefix), []byte(field.Name)
+	default:
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("sealed %s is not valid base64: %w", field.Name, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed, additional)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", field.Name, err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the random nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+// Keyring holds the master key and caches the data keys unwrapped with it.
+type Keyring struct {
+	master *Key
+	mu     sync.Mutex
+	keys   map[int64][]byte
+}
+
+func NewKeyring(master *Key) *Keyring {
+	return &Keyring{master: master, keys: make(map[int64][]byte)}
+}
+
+func (r *Keyring) Master() *Key {
+	return r.master
+}
+
+// Get returns the cached data key of userID.
+func (r *Keyring) Get(userID int64) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[userID]
+	return key, ok
+}
+
+// Put caches the data key of userID. Only keys known to be committed to the
+// database may be cached.
+func (r *Keyring) Put(userID int64, key []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[userID] = key
+}
+
+// Unwrap returns the data key of userID from its stored form: wrapped by the
+// master key with ID masterKeyID, which must be the master key of r.
+func (r *Keyring) Unwrap(userID int64, wrapped []byte, masterKeyID string) ([]byte, error) {
+	if masterKeyID != r.master.ID() {
+		return nil, fmt.Errorf("user %d: %w", userID, ErrWrongMasterKey)
+	}
+	return r.master.Unwrap(wrapped)
+}
+
+// Rewrap returns a data key stored wrapped by the master key with ID
+// masterKeyID wrapped by next instead, or nil if next already wraps it. The
+// old master key must be the one of current, which is nil when none is
+// configured.
+func Rewrap(current *Keyring, next *Key, wrapped []byte, masterKeyID string) ([]byte, error) {
+	if masterKeyID == next.ID() {
+		return nil, nil
+	}
+	if current == nil || masterKeyID != current.master.ID() {
+		return nil, ErrWrongMasterKey
+	}
+	dataKey, err := current.master.Unwrap(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return next.Wrap(dataKey)
+}
+
+// Rekeyer is a storage that can move its data keys to a new master key.
+type Rekeyer interface {
+	Rekey(ctx context.Context, next *Key) (RekeyResult, error)
+}
+
+// RekeyResult counts what a rekey changed.
+type RekeyResult struct {
+	// Rewrapped is the number of data keys now wrapped by the new master key.
+	Rewrapped int
+	// Sealed is the number of rows whose plain text fields, or fields sealed
+	// in an older format, were sealed.
+	Sealed int
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) LoadExerciseTranslations(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadExerciseTranslations")
+	exerciseID, err := strconv.ParseInt(c.Param("exerciseId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	translations, err := h.storage.GetTranslationsOfExercise(c.Request.Context(), exerciseID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"translations": translations})
+}
+
+// SetExerciseTranslation adds or replaces the translation of a global
+// exercise to the locale in the path.
+func (h *Handler) SetExerciseTranslation(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling SetExerciseTranslation")
+	exerciseID, err := strconv.ParseInt(c.Param("exerciseId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	locale, ok := parseLocale(c.Param("locale"))
+	if !ok {
+		c.JSON(400, gin.H{"error": "Invalid locale, must be a language tag such as en or en-GB"})
+		return
+	}
+	if locale == storage.DefaultLocale {
+		c.JSON(400, gin.H{"error": "The default locale is edited in the catalog itself"})
+		return
+	}
+	var request struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || utf8.RuneCountInString(request.Name) > 100 {
+		c.JSON(400, gin.H{"error": "Invalid name, must be 1 to 100 characters"})
+		return
+	}
+	translation := storage.ExerciseTranslation{
+		ExerciseID:  exerciseID,
+		Locale:      locale,
+		Name:        request.Name,
+		Description: request.Description,
+	}
+	if err := h.storage.PutExerciseTranslation(c.Request.Context(), translation); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, translation)
+}
+
+func (h *Handler) DeleteExerciseTranslation(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteExerciseTranslation")
+	exerciseID, err := strconv.ParseInt(c.Param("exerciseId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	locale, ok := parseLocale(c.Param("locale"))
+	if !ok {
+		c.JSON(400, gin.H{"error": "Invalid locale, must be a language tag such as en or en-GB"})
+		return
+	}
+	if err := h.storage.DeleteExerciseTranslation(c.Request.Context(), exerciseID, locale); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Translation deleted"})
+}
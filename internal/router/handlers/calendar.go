@@ -1,13 +1,35 @@
 package handlers
 
 import (
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	dateLayout           = "2006-01-02"
+	timeLayout           = "15:04:05"
+	calendarMinDate      = "0001-01-01"
+	calendarMaxDate      = "9999-12-31"
+	calendarDefaultLimit = 31
+	calendarMaxLimit     = 366
+)
+
+func isValidDate(date string) bool {
+	_, err := time.Parse(dateLayout, date)
+	return err == nil
+}
+
 func (h *Handler) LoadCalendar(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
 	logger.Debug("handling load user requeset")
@@ -23,10 +45,41 @@ func (h *Handler) LoadCalendar(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "User id is not integer"})
 		return
 	}
-	workoutsInfo, err := h.storage.GetAllWorkouts(user_ID)
+	from := c.DefaultQuery("from", calendarMinDate)
+	to := c.DefaultQuery("to", calendarMaxDate)
+	if !isValidDate(from) || !isValidDate(to) || from > to {
+		logger.Error("invalid date range", "from", from, "to", to)
+		c.JSON(400, gin.H{"error": "from and to must be dates in YYYY-MM-DD format, from not after to"})
+		return
+	}
+	limit := calendarDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > calendarMaxLimit {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid limit, must be an integer between 1 and %d", calendarMaxLimit)})
+			return
+		}
+		limit = parsed
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		cursorDate, err := time.Parse(dateLayout, cursor)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		if next := cursorDate.AddDate(0, 0, 1).Format(dateLayout); next > from {
+			from = next
+		}
+	}
+	// One extra day tells whether there is a next page.
+	days, err := h.storage.GetCalendarDays(c.Request.Context(), user_ID, from, to, limit+1)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	planned, err := h.plannedEntries(c.Request.Context(), user_ID, from, to, limit+1)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	type WorkoutTimings struct {
@@ -35,25 +88,46 @@ func (h *Handler) LoadCalendar(c *gin.Context) {
 	}
 	type Day struct {
 		Date            string           `json:"date"`
+		WorkoutCount    int              `json:"workoutCount"`
 		WorkoutsTimings []WorkoutTimings `json:"workoutsTimings"`
+		Planned         []plannedEntry   `json:"planned"`
 	}
 	type Request struct {
-		Days []Day `json:"days"`
+		Days       []Day  `json:"days"`
+		NextCursor string `json:"nextCursor,omitempty"`
+	}
+	byDate := make(map[string]*Day)
+	var dates []string
+	dayOf := func(date string) *Day {
+		if day, ok := byDate[date]; ok {
+			return day
+		}
+		byDate[date] = &Day{Date: date, WorkoutsTimings: []WorkoutTimings{}, Planned: []plannedEntry{}}
+		dates = append(dates, date)
+		return byDate[date]
+	}
+	for _, calendarDay := range days {
+		day := dayOf(calendarDay.Date)
+		day.WorkoutCount = len(calendarDay.Workouts)
+		for _, workout := range calendarDay.Workouts {
+			day.WorkoutsTimings = append(day.WorkoutsTimings, WorkoutTimings{
+				StartTime: workout.StartTime,
+				EndTime:   workout.EndTime,
+			})
+		}
+	}
+	for _, entry := range planned {
+		day := dayOf(entry.Date)
+		day.Planned = append(day.Planned, entry)
 	}
+	slices.Sort(dates)
 	var request Request
-	workoutsGrouped := make(map[string][]WorkoutTimings)
-	for _, workout := range workoutsInfo {
-		workoutsGrouped[workout.Date] = append(workoutsGrouped[workout.Date], WorkoutTimings{
-			StartTime: workout.StartTime,
-			EndTime:   workout.EndTime,
-		})
+	if len(dates) > limit {
+		dates = dates[:limit]
+		request.NextCursor = dates[limit-1]
 	}
-
-	for date, workoutList := range workoutsGrouped {
-		request.Days = append(request.Days, Day{
-			Date:            date,
-			WorkoutsTimings: workoutList,
-		})
+	for _, date := range dates {
+		request.Days = append(request.Days, *byDate[date])
 	}
 
 	c.JSON(200, request)
@@ -91,15 +165,25 @@ func (h *Handler) LoadTrainings(c *gin.Context) {
 		StartTime        string             `json:"timeStart"`
 		EndTime          string             `json:"timeEnd"`
 		Notes            string             `json:"notes"`
+		Version          int64              `json:"version"`
 		WorkoutExercises []WorkoutExercises `json:"workoutExercises"`
 	}
 	type Request struct {
 		TrainingsInDay []TrainingsInDay `json:"trainingsInDay"`
 	}
-	workoutsInfo, err := h.storage.GetWorkoutsFromDate(user_ID, date)
+	workoutsInfo, err := h.storage.GetWorkoutSummariesInRange(c.Request.Context(), user_ID, date, date)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
+		return
+	}
+	locale, err := h.locale(c, user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	translations, err := h.exerciseTranslations(c.Request.Context(), locale)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 	var request Request
@@ -110,23 +194,12 @@ func (h *Handler) LoadTrainings(c *gin.Context) {
 			StartTime: workout.StartTime,
 			EndTime:   workout.EndTime,
 			Notes:     workout.Notes,
+			Version:   workout.Version,
 		}
-		exercises, err := h.storage.GetWorkoutExercises(workout.WorkoutID)
-		if err != nil {
-			logger.Error("Internal server error while accessing the DB", "error", err)
-			c.JSON(500, gin.H{"error": "Internal server error"})
-			return
-		}
-		for _, exercise := range exercises {
-			allowedExercise, err := h.storage.GetAllowedExercise(exercise.ExerciseID)
-			if err != nil {
-				logger.Error("Internal server error while accessing the DB", "error", err)
-				c.JSON(500, gin.H{"error": "Internal server error"})
-				return
-			}
+		for _, exercise := range workout.Exercises {
 			training.WorkoutExercises = append(training.WorkoutExercises, WorkoutExercises{
 				WorkoutExerciseID: exercise.WorkoutExerciseID,
-				ExerciseName:      allowedExercise.Name,
+				ExerciseName:      exerciseName(exercise.ExerciseID, exercise.ExerciseName, translations),
 			})
 		}
 		request.TrainingsInDay = append(request.TrainingsInDay, training)
@@ -149,14 +222,13 @@ func (h *Handler) CreateTraining(c *gin.Context) {
 		return
 	}
 
-	var setInfo sqlite.Workout
-	logger.Debug("log:", "w", setInfo)
+	var setInfo storage.Workout
 	if err := c.ShouldBindJSON(&setInfo); err != nil {
 		logger.Error("invalid request body", "error", err)
 		c.JSON(400, gin.H{"error": "invalid request body"})
 		return
 	}
-	var workout = sqlite.Workout{
+	var workout = storage.Workout{
 		UserID:    user_ID,
 		Date:      setInfo.Date,
 		StartTime: setInfo.StartTime,
@@ -164,12 +236,18 @@ func (h *Handler) CreateTraining(c *gin.Context) {
 		Notes:     setInfo.Notes,
 		Photo:     setInfo.Photo,
 	}
-	if err := h.storage.AddWorkout(workout); err != nil {
-		logger.Error("failed to create workout", "error", err)
-		c.JSON(500, gin.H{"error": "failed to create workout"})
+	workoutID, err := h.storage.AddWorkout(c.Request.Context(), workout)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(201, workout)
+	c.Header("ETag", etag(created.Version))
+	c.JSON(201, created)
 }
 func (h *Handler) LoadTrainingSingle(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
@@ -204,21 +282,22 @@ func (h *Handler) LoadTrainingSingle(c *gin.Context) {
 		return
 	}
 	type WorkoutExercises struct {
-		WorkoutExerciseID int64            `json:"workoutExerciseId"`
-		ExerciseName      string           `json:"exerciseName"`
-		Sets              []sqlite.SetInfo `json:"sets"`
+		WorkoutExerciseID int64             `json:"workoutExerciseId"`
+		ExerciseName      string            `json:"exerciseName"`
+		Sets              []storage.SetInfo `json:"sets"`
 	}
 	type Training struct {
-		StartTime        string                       `json:"timeStart"`
-		EndTime          string                       `json:"timeEnd"`
-		Notes            string                       `json:"notes"`
-		WorkoutExercises []WorkoutExercises           `json:"workoutExercises"`
-		ListOfExercises  []sqlite.AllowedExerciseInfo `json:"listOfExercises"`
-	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+		StartTime        string                        `json:"timeStart"`
+		EndTime          string                        `json:"timeEnd"`
+		Notes            string                        `json:"notes"`
+		WeightUnit       storage.WeightUnit            `json:"weightUnit"`
+		BodyWeight       *float64                      `json:"bodyWeight"`
+		WorkoutExercises []WorkoutExercises            `json:"workoutExercises"`
+		ListOfExercises  []storage.AllowedExerciseInfo `json:"listOfExercises"`
+	}
+	workoutInfo, err := h.storage.GetWorkoutDetails(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -226,44 +305,55 @@ func (h *Handler) LoadTrainingSingle(c *gin.Context) {
 		c.JSON(403, gin.H{"error": "Access Denied"})
 		return
 	}
-	listOfExercises, err := h.storage.GetAllowedExercises()
+	listOfExercises, err := h.storage.GetAllowedExercises(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	customExercises, err := h.storage.GetCustomExercises(c.Request.Context(), user_ID)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
+		return
+	}
+	listOfExercises = append(listOfExercises, customExercises...)
+	locale, err := h.locale(c, user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	translations, err := h.exerciseTranslations(c.Request.Context(), locale)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i := range listOfExercises {
+		translateExercise(&listOfExercises[i], translations)
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 	training := Training{
 		StartTime:       workoutInfo.StartTime,
 		EndTime:         workoutInfo.EndTime,
 		Notes:           workoutInfo.Notes,
+		WeightUnit:      unit,
+		BodyWeight:      weightFromKg(unit, workoutInfo.BodyWeight),
 		ListOfExercises: listOfExercises,
 	}
-	exercises, err := h.storage.GetWorkoutExercises(workoutInfo.WorkoutID)
-	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
-		return
-	}
-	for _, exercise := range exercises {
-		allowedExercise, err := h.storage.GetAllowedExercise(exercise.ExerciseID)
-		if err != nil {
-			logger.Error("Internal server error while accessing the DB", "error", err)
-			c.JSON(500, gin.H{"error": "Internal server error"})
-			return
-		}
-
-		sets, err := h.storage.GetSets(exercise.WorkoutExerciseID)
-		if err != nil {
-			logger.Error("Internal server error while accessing the DB", "error", err)
-			c.JSON(500, gin.H{"error": "Internal server error"})
-			return
+	for _, exercise := range workoutInfo.Exercises {
+		for i := range exercise.Sets {
+			exercise.Sets[i].Weight = unit.FromKg(exercise.Sets[i].Weight)
+			exercise.Sets[i].EffectiveLoad = weightFromKg(unit, exercise.Sets[i].EffectiveLoad)
 		}
 		training.WorkoutExercises = append(training.WorkoutExercises, WorkoutExercises{
 			WorkoutExerciseID: exercise.WorkoutExerciseID,
-			ExerciseName:      allowedExercise.Name,
-			Sets:              sets,
+			ExerciseName:      exerciseName(exercise.ExerciseID, exercise.ExerciseName, translations),
+			Sets:              exercise.Sets,
 		})
 	}
+	c.Header("ETag", etag(workoutInfo.Version))
 	c.JSON(200, training)
 }
 func (h *Handler) CreateSets(c *gin.Context) {
@@ -298,10 +388,9 @@ func (h *Handler) CreateSets(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
 		return
 	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -312,10 +401,11 @@ func (h *Handler) CreateSets(c *gin.Context) {
 	type SingleSet struct {
 		Weight      float64 `json:"weight"`
 		Repetitions int     `json:"repetitions"`
+		storage.SetDetails
 	}
 	type Sets struct {
-		AllowedExercise sqlite.AllowedExerciseInfo `json:"allowedExercise"`
-		Sets            []SingleSet                `json:"sets"`
+		AllowedExercise storage.AllowedExerciseInfo `json:"allowedExercise"`
+		Sets            []SingleSet                 `json:"sets"`
 	}
 	var setsInfo Sets
 	if err := c.ShouldBindJSON(&setsInfo); err != nil {
@@ -323,24 +413,65 @@ func (h *Handler) CreateSets(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid request body"})
 		return
 	}
-	workoutExerciseId, err := h.storage.AddWorkoutExercise(sqlite.WorkoutExercise{
-		WorkoutID:  workoutId,
-		ExerciseID: setsInfo.AllowedExercise.AllowedExerciseId,
-	})
+	// Weights are entered in the user's unit and stored in kilograms.
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
+		return
+	}
+	// The exercise and its sets are written together, so a failure part way
+	// does not leave an empty exercise attached to the workout.
+	var (
+		sets       []storage.Set
+		invalidSet error
+	)
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		exerciseID := setsInfo.AllowedExercise.AllowedExerciseId
+		// What a set must record depends on the exercise.
+		exercise, err := tx.GetAllowedExercise(c.Request.Context(), exerciseID)
+		if err == nil && !exercise.VisibleTo(user_ID) {
+			err = storage.ErrNotFound
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("allowed exercise %d: %w", exerciseID, storage.ErrForeignKey)
+		}
+		if err != nil {
+			return err
+		}
+		for i, set := range setsInfo.Sets {
+			if invalidSet = validateSet(exercise, set.Repetitions, set.Weight, &setsInfo.Sets[i].SetDetails); invalidSet != nil {
+				return invalidSet
+			}
+		}
+		workoutExerciseId, err := tx.AddWorkoutExercise(c.Request.Context(), storage.WorkoutExercise{
+			WorkoutID:  workoutId,
+			ExerciseID: exerciseID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, set := range setsInfo.Sets {
+			sets = append(sets, storage.Set{WorkoutExerciseID: workoutExerciseId, Repetitions: set.Repetitions, Weight: unit.ToKg(set.Weight), SetDetails: set.SetDetails})
+		}
+		return tx.AddSets(c.Request.Context(), sets)
+	})
+	if invalidSet != nil {
+		logger.Error("Invalid set", "error", invalidSet)
+		c.JSON(400, gin.H{"error": invalidSet.Error()})
 		return
 	}
-	var sets []sqlite.Set
-	for _, set := range setsInfo.Sets {
-		sets = append(sets, sqlite.Set{WorkoutExerciseID: workoutExerciseId, Repetitions: set.Repetitions, Weight: set.Weight})
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
-	if err := h.storage.AddSets(sets); err != nil {
-		logger.Error("failed to create sets", "error", err)
-		c.JSON(500, gin.H{"error": "failed to create set"})
+	if workoutInfo, err = h.storage.GetWorkoutFromID(c.Request.Context(), workoutId); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	for i := range sets {
+		sets[i].Weight = unit.FromKg(sets[i].Weight)
+	}
+	c.Header("ETag", etag(workoutInfo.Version))
 	c.JSON(201, sets)
 }
 func (h *Handler) DeleteExercise(c *gin.Context) {
@@ -369,10 +500,9 @@ func (h *Handler) DeleteExercise(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
 		return
 	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -380,9 +510,13 @@ func (h *Handler) DeleteExercise(c *gin.Context) {
 		c.JSON(403, gin.H{"error": "Access Denied"})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 	type Exercise struct {
-		Sets              []sqlite.SetInfo `json:"sets"`
-		WorkoutExerciseId int64            `json:"workoutExerciseId"`
+		Sets              []storage.SetInfo `json:"sets"`
+		WorkoutExerciseId int64             `json:"workoutExerciseId"`
 	}
 	var exerciseInfo Exercise
 	if err := c.ShouldBindJSON(&exerciseInfo); err != nil {
@@ -390,11 +524,15 @@ func (h *Handler) DeleteExercise(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid request body"})
 		return
 	}
-	if err := h.storage.DeleteWorkoutExercise(exerciseInfo.WorkoutExerciseId); err != nil {
-		logger.Error("Internal server error", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+	if err := h.storage.DeleteWorkoutExercise(c.Request.Context(), workoutId, version, exerciseInfo.WorkoutExerciseId); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	if workoutInfo, err = h.storage.GetWorkoutFromID(c.Request.Context(), workoutId); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag(workoutInfo.Version))
 	c.JSON(200, exerciseInfo)
 }
 func (h *Handler) ChangeExercise(c *gin.Context) {
@@ -423,10 +561,9 @@ func (h *Handler) ChangeExercise(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
 		return
 	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -435,18 +572,22 @@ func (h *Handler) ChangeExercise(c *gin.Context) {
 		return
 	}
 	exerciseIdStr := c.Param("exerciseId")
-	if workoutIdStr == "" {
-		logger.Error("workoutId is required")
-		c.JSON(400, gin.H{"error": "WorkoutId is required"})
+	if exerciseIdStr == "" {
+		logger.Error("exerciseId is required")
+		c.JSON(400, gin.H{"error": "ExerciseId is required"})
 		return
 	}
 	exerciseId, err := strconv.ParseInt(exerciseIdStr, 10, 64)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 	type Exercise struct {
-		Sets []sqlite.SetInfo `json:"sets"`
+		Sets []storage.SetInfo `json:"sets"`
 	}
 	var exerciseInfo Exercise
 	if err := c.ShouldBindJSON(&exerciseInfo); err != nil {
@@ -454,11 +595,42 @@ func (h *Handler) ChangeExercise(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid request body"})
 		return
 	}
-	if err := h.storage.ReplaceSets(exerciseId, exerciseInfo.Sets); err != nil {
-		logger.Error("Internal server error", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+	workoutExercise, err := h.storage.GetWorkoutExercise(c.Request.Context(), exerciseId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	exercise, err := h.storage.GetAllowedExercise(c.Request.Context(), workoutExercise.ExerciseID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i, set := range exerciseInfo.Sets {
+		if err := validateSet(exercise, set.Repetitions, set.Weight, &exerciseInfo.Sets[i].SetDetails); err != nil {
+			logger.Error("Invalid set", "error", err)
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		exerciseInfo.Sets[i].Weight = unit.ToKg(set.Weight)
+		exerciseInfo.Sets[i].EffectiveLoad = nil
+	}
+	if err := h.storage.ReplaceSets(c.Request.Context(), workoutId, version, exerciseId, exerciseInfo.Sets); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i := range exerciseInfo.Sets {
+		exerciseInfo.Sets[i].Weight = unit.FromKg(exerciseInfo.Sets[i].Weight)
+	}
+	if workoutInfo, err = h.storage.GetWorkoutFromID(c.Request.Context(), workoutId); err != nil {
+		_ = c.Error(err)
 		return
 	}
+	c.Header("ETag", etag(workoutInfo.Version))
 	type RequestInfo struct {
 		ExeExercise Exercise `json:"ExeExercise"`
 		ExerciseId  int64    `json:"exerciseId"`
@@ -495,10 +667,9 @@ func (h *Handler) ChangeWorkoutInfo(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
 		return
 	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -506,46 +677,148 @@ func (h *Handler) ChangeWorkoutInfo(c *gin.Context) {
 		c.JSON(403, gin.H{"error": "Access Denied"})
 		return
 	}
-	var updateData sqlite.WorkoutInfo
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	// The body is a JSON merge patch: absent fields are left alone and null
+	// clears a field.
+	var patch storage.WorkoutPatch
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
 		logger.Error("Invalid request body", "error", err)
 		c.JSON(400, gin.H{"error": "Invalid request body"})
 		return
 	}
-	updateData.WorkoutID = workoutId
-	updateData.UserID = user_ID
-	updatedFields := getChangedFields(*workoutInfo, updateData)
-	if err := h.storage.PartialUpdateWorkout(workoutId, updatedFields); err != nil {
-		logger.Error("Failed to update workout", "error", err)
-		c.JSON(500, gin.H{"error": "Failed to update workout"})
+	if err := validateWorkoutPatch(&patch); err != nil {
+		logger.Error("Invalid workout patch", "error", err)
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	patch = patch.Changes(*workoutInfo)
+	if err := h.storage.PartialUpdateWorkout(c.Request.Context(), workoutId, version, patch); err != nil {
+		_ = c.Error(err)
 		return
 	}
-	workoutInfo, err = h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err = h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(200, workoutInfo)
+	c.Header("ETag", etag(workoutInfo.Version))
+	c.JSON(200, gin.H{"workout": workoutInfo, "changed": patch.Fields()})
 }
-func getChangedFields(original, updated sqlite.WorkoutInfo) map[string]interface{} {
-	changes := make(map[string]interface{})
-	if updated.Date != "" && updated.Date != original.Date {
-		changes["workout_date"] = updated.Date
+
+// validateWorkoutPatch checks the values in p and brings its times to
+// timeLayout. The date and start time can be changed but not cleared.
+func validateWorkoutPatch(p *storage.WorkoutPatch) error {
+	if p.Date.Set && (p.Date.Null || !isValidDate(p.Date.Value)) {
+		return errors.New("Invalid date, must be YYYY-MM-DD")
+	}
+	if p.StartTime.Set {
+		startTime, ok := normalizeTime(p.StartTime.Value)
+		if p.StartTime.Null || !ok {
+			return errors.New("Invalid startTime, must be HH:MM or HH:MM:SS")
+		}
+		p.StartTime.Value = startTime
 	}
-	if updated.StartTime != "" && updated.StartTime != original.StartTime {
-		changes["workout_start_time "] = updated.StartTime
+	if p.EndTime.Set && p.EndTime.Value != "" {
+		endTime, ok := normalizeTime(p.EndTime.Value)
+		if !ok {
+			return errors.New("Invalid endTime, must be HH:MM or HH:MM:SS")
+		}
+		p.EndTime.Value = endTime
 	}
-	if updated.EndTime != "" && updated.EndTime != original.EndTime {
-		changes["workout_end_time"] = updated.EndTime
+	// Photos are files in the pictures directory, named by the upload.
+	if p.Photo.Set && p.Photo.Value != "" && (strings.ContainsAny(p.Photo.Value, `/\`) || p.Photo.Value == "..") {
+		return errors.New("Invalid photo, must be a file name")
 	}
-	if updated.Notes != original.Notes {
-		changes["notes"] = updated.Notes
+	return nil
+}
+
+// tempoPattern is four phases of a repetition in seconds, X for explosive.
+var tempoPattern = regexp.MustCompile(`^[0-9X]-[0-9X]-[0-9X]-[0-9X]$`)
+
+// validateSet checks a set of exercise, fills in the default set type and
+// derives the pace and speed.
+func validateSet(exercise storage.AllowedExerciseInfo, repetitions int, weight float64, d *storage.SetDetails) error {
+	// A negative weight is the assistance on a bodyweight exercise.
+	if repetitions < 0 || weight < 0 && !exercise.Bodyweight {
+		return errors.New("Invalid set, repetitions and weight cannot be negative")
+	}
+	if d.DurationSeconds != nil && *d.DurationSeconds <= 0 || d.DistanceMeters != nil && *d.DistanceMeters <= 0 {
+		return errors.New("Invalid set, durationSeconds and distanceMeters must be positive")
+	}
+	switch exercise.MeasurementType {
+	case storage.MeasurementTime:
+		if d.DurationSeconds == nil || d.DistanceMeters != nil || repetitions != 0 {
+			return errors.New("Invalid set, a timed exercise records durationSeconds only")
+		}
+	case storage.MeasurementDistanceTime:
+		if d.DurationSeconds == nil || d.DistanceMeters == nil || repetitions != 0 || weight != 0 {
+			return errors.New("Invalid set, a distance exercise records distanceMeters and durationSeconds")
+		}
+	case storage.MeasurementReps:
+		if d.DurationSeconds != nil || d.DistanceMeters != nil || weight != 0 {
+			return errors.New("Invalid set, this exercise records repetitions only")
+		}
+	default:
+		if d.DurationSeconds != nil || d.DistanceMeters != nil {
+			return errors.New("Invalid set, this exercise records repetitions and weight")
+		}
 	}
-	if updated.Photo != original.Photo {
-		changes["photo"] = updated.Photo
+	if err := validateSetDetails(d); err != nil {
+		return err
 	}
-	return changes
+	d.Derive()
+	return nil
+}
+
+// weightFromKg converts an optional weight from kilograms to unit.
+func weightFromKg(unit storage.WeightUnit, weight *float64) *float64 {
+	if weight == nil {
+		return nil
+	}
+	converted := unit.FromKg(*weight)
+	return &converted
+}
+
+// validateSetDetails checks the optional data of a set and fills in the
+// default set type.
+func validateSetDetails(d *storage.SetDetails) error {
+	if d.Type == "" {
+		d.Type = storage.SetTypeWorking
+	}
+	if !d.Type.Valid() {
+		return errors.New("Invalid type, must be one of warmup, working, drop, failure, amrap")
+	}
+	if d.RPE != nil && (*d.RPE < 1 || *d.RPE > 10 || math.Mod(*d.RPE*2, 1) != 0) {
+		return errors.New("Invalid rpe, must be between 1 and 10 in steps of 0.5")
+	}
+	if d.RIR != nil && (*d.RIR < 0 || *d.RIR > 10) {
+		return errors.New("Invalid rir, must be between 0 and 10")
+	}
+	if d.RestSeconds != nil && (*d.RestSeconds < 0 || *d.RestSeconds > 3600) {
+		return errors.New("Invalid restSeconds, must be between 0 and 3600")
+	}
+	if d.Tempo != nil {
+		tempo := strings.ToUpper(*d.Tempo)
+		if !tempoPattern.MatchString(tempo) {
+			return errors.New("Invalid tempo, must look like 3-1-X-0")
+		}
+		d.Tempo = &tempo
+	}
+	return nil
+}
+
+func normalizeTime(value string) (string, bool) {
+	for _, layout := range []string{timeLayout, "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(timeLayout), true
+		}
+	}
+	return "", false
 }
 func (h *Handler) DeleteWorkout(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
@@ -573,10 +846,9 @@ func (h *Handler) DeleteWorkout(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
 		return
 	}
-	workoutInfo, err := h.storage.GetWorkoutFromID(workoutId)
+	workoutInfo, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
 	if err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	if workoutInfo.UserID != user_ID {
@@ -584,10 +856,13 @@ func (h *Handler) DeleteWorkout(c *gin.Context) {
 		c.JSON(403, gin.H{"error": "Access Denied"})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	if err = h.storage.DeleteWorkout(workoutId); err != nil {
-		logger.Error("Internal server error while accessing the DB", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+	if err = h.storage.DeleteWorkout(c.Request.Context(), workoutId, version); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(200, workoutInfo)
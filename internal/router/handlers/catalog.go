@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadCatalog lists the global exercises and the user's custom ones with
+// their taxonomy. The muscle, primaryMuscle, equipment and pattern query
+// parameters filter by code; primaryMuscle only matches primary movers. Names
+// are translated to the negotiated locale.
+func (h *Handler) LoadCatalog(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadCatalog")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	filter := storage.CatalogFilter{
+		Muscle:          c.Query("muscle"),
+		Equipment:       c.Query("equipment"),
+		MovementPattern: c.Query("pattern"),
+	}
+	if primary := c.Query("primaryMuscle"); primary != "" {
+		if filter.Muscle != "" {
+			c.JSON(400, gin.H{"error": "muscle and primaryMuscle cannot be combined"})
+			return
+		}
+		filter.Muscle, filter.PrimaryMuscle = primary, true
+	}
+	exercises, err := h.storage.GetCatalog(c.Request.Context(), user_ID, filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	locale, err := h.locale(c, user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	translations, err := h.exerciseTranslations(c.Request.Context(), locale)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i := range exercises {
+		translateExercise(&exercises[i].AllowedExerciseInfo, translations)
+	}
+	c.JSON(200, gin.H{"exercises": exercises})
+}
+
+func (h *Handler) LoadTaxonomy(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadTaxonomy")
+	taxonomy, err := h.storage.GetTaxonomy(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, taxonomy)
+}
+
+// validateTaxonomy checks that no muscle group or equipment is listed twice
+// and replaces missing lists with empty ones. Unknown codes are left for the
+// storage to reject.
+func validateTaxonomy(t *storage.ExerciseTaxonomy) error {
+	muscles := make(map[string]bool)
+	for _, code := range append(append([]string{}, t.PrimaryMuscles...), t.SecondaryMuscles...) {
+		if muscles[code] {
+			return fmt.Errorf("Invalid taxonomy, muscle group %q is listed twice", code)
+		}
+		muscles[code] = true
+	}
+	equipment := make(map[string]bool)
+	for _, code := range t.Equipment {
+		if equipment[code] {
+			return fmt.Errorf("Invalid taxonomy, equipment %q is listed twice", code)
+		}
+		equipment[code] = true
+	}
+	if t.MovementPattern != nil && *t.MovementPattern == "" {
+		return errors.New("Invalid taxonomy, movementPattern cannot be empty")
+	}
+	if t.PrimaryMuscles == nil {
+		t.PrimaryMuscles = []string{}
+	}
+	if t.SecondaryMuscles == nil {
+		t.SecondaryMuscles = []string{}
+	}
+	if t.Equipment == nil {
+		t.Equipment = []string{}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a workout version as a strong entity tag.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion returns the workout version named by the If-Match header.
+// Writes to a workout must say which version they were made against; when the
+// header is missing or cannot name a version, ifMatchVersion answers the
+// request itself and returns false.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err == nil {
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil {
+			return version, true
+		}
+	}
+	// An entity tag we never issued cannot match the current one.
+	c.JSON(412, gin.H{"error": "Precondition failed"})
+	return 0, false
+}
@@ -1,15 +1,15 @@
 package handlers
 
 import (
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
 	"log/slog"
 )
 
 type Handler struct {
-	storage *sqlite.Storage
+	storage storage.Storage
 	log     *slog.Logger
 }
 
-func NewHandlers(storage *sqlite.Storage, log *slog.Logger) *Handler {
+func NewHandlers(storage storage.Storage, log *slog.Logger) *Handler {
 	return &Handler{storage: storage, log: log}
 }
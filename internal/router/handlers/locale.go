@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// locale picks the locale exercise names are shown in: the user's preference
+// if they set one, otherwise the best match for Accept-Language among the
+// locales the catalog has translations for. Anything unmatched falls back to
+// storage.DefaultLocale. The choice is echoed in Content-Language.
+func (h *Handler) locale(c *gin.Context, userID int64) (string, error) {
+	preferred, err := h.storage.GetLocale(c.Request.Context(), userID)
+	if err != nil {
+		return "", err
+	}
+	translated, err := h.storage.GetLocales(c.Request.Context())
+	if err != nil {
+		return "", err
+	}
+	available := []string{storage.DefaultLocale}
+	for _, l := range translated {
+		if l != storage.DefaultLocale {
+			available = append(available, l)
+		}
+	}
+
+	var desired []language.Tag
+	if preferred != "" {
+		desired, _, err = language.ParseAcceptLanguage(preferred)
+	} else {
+		desired, _, err = language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	}
+	locale := storage.DefaultLocale
+	if err == nil && len(desired) > 0 {
+		tags := make([]language.Tag, 0, len(available))
+		for _, l := range available {
+			tags = append(tags, language.Make(l))
+		}
+		_, index, confidence := language.NewMatcher(tags).Match(desired...)
+		if confidence != language.No {
+			locale = available[index]
+		}
+	}
+	c.Header("Content-Language", locale)
+	return locale, nil
+}
+
+// exerciseTranslations returns the translations to locale keyed by exercise,
+// nil for the default locale.
+func (h *Handler) exerciseTranslations(ctx context.Context, locale string) (map[int64]storage.ExerciseTranslation, error) {
+	if locale == storage.DefaultLocale {
+		return nil, nil
+	}
+	translations, err := h.storage.GetExerciseTranslations(ctx, locale)
+	if err != nil {
+		return nil, err
+	}
+	byExercise := make(map[int64]storage.ExerciseTranslation, len(translations))
+	for _, t := range translations {
+		byExercise[t.ExerciseID] = t
+	}
+	return byExercise, nil
+}
+
+// translateExercise replaces the name and description of e in place. An
+// exercise without a translation, custom ones included, keeps its own.
+func translateExercise(e *storage.AllowedExerciseInfo, translations map[int64]storage.ExerciseTranslation) {
+	if t, ok := translations[e.AllowedExerciseId]; ok {
+		e.Name = t.Name
+		if t.Description != "" {
+			e.Description = t.Description
+		}
+	}
+}
+
+// exerciseName returns the translated name of an exercise, or name when
+// there is none.
+func exerciseName(exerciseID int64, name string, translations map[int64]storage.ExerciseTranslation) string {
+	if t, ok := translations[exerciseID]; ok {
+		return t.Name
+	}
+	return name
+}
+
+// parseLocale validates a BCP 47 language tag and returns it in the
+// canonical lower-case form locales are stored in.
+func parseLocale(s string) (string, bool) {
+	tag, err := language.Parse(s)
+	if err != nil || tag == language.Und {
+		return "", false
+	}
+	return strings.ToLower(tag.String()), true
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type preferences struct {
+	WeightUnit storage.WeightUnit `json:"weightUnit"`
+	// Locale is nil while exercise names follow Accept-Language.
+	Locale *string `json:"locale"`
+}
+
+func (h *Handler) GetPreferences(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling GetPreferences")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	prefs, err := h.preferences(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, prefs)
+}
+
+// ChangePreferences applies a merge patch to the preferences. A null locale
+// goes back to following Accept-Language.
+func (h *Handler) ChangePreferences(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling ChangePreferences")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	var request struct {
+		WeightUnit storage.Optional[storage.WeightUnit] `json:"weightUnit"`
+		Locale     storage.Optional[string]             `json:"locale"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	if request.WeightUnit.Set && (request.WeightUnit.Null || !request.WeightUnit.Value.Valid()) {
+		c.JSON(400, gin.H{"error": "Invalid weightUnit, must be kg or lb"})
+		return
+	}
+	locale := request.Locale.Value
+	if request.Locale.Set && !request.Locale.Null {
+		if locale, ok = parseLocale(request.Locale.Value); !ok {
+			c.JSON(400, gin.H{"error": "Invalid locale, must be a language tag such as en or en-GB"})
+			return
+		}
+	}
+	err := h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		if request.WeightUnit.Set {
+			if err := tx.SetWeightUnit(c.Request.Context(), user_ID, request.WeightUnit.Value); err != nil {
+				return err
+			}
+		}
+		if request.Locale.Set {
+			return tx.SetLocale(c.Request.Context(), user_ID, locale)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	prefs, err := h.preferences(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, prefs)
+}
+
+func (h *Handler) preferences(ctx context.Context, userID int64) (preferences, error) {
+	unit, err := h.storage.GetWeightUnit(ctx, userID)
+	if err != nil {
+		return preferences{}, err
+	}
+	locale, err := h.storage.GetLocale(ctx, userID)
+	if err != nil {
+		return preferences{}, err
+	}
+	prefs := preferences{WeightUnit: unit}
+	if locale != "" {
+		prefs.Locale = &locale
+	}
+	return prefs, nil
+}
+
+// LoadBodyWeights lists the body weights logged between the optional from and
+// to dates, in the user's unit.
+func (h *Handler) LoadBodyWeights(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadBodyWeights")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	from := c.DefaultQuery("from", calendarMinDate)
+	to := c.DefaultQuery("to", calendarMaxDate)
+	if !isValidDate(from) || !isValidDate(to) || from > to {
+		logger.Error("invalid date range", "from", from, "to", to)
+		c.JSON(400, gin.H{"error": "from and to must be dates in YYYY-MM-DD format, from not after to"})
+		return
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	entries, err := h.storage.GetBodyWeights(c.Request.Context(), user_ID, from, to)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i := range entries {
+		entries[i].Weight = unit.FromKg(entries[i].Weight)
+	}
+	c.JSON(200, gin.H{"weightUnit": unit, "bodyWeights": entries})
+}
+
+// SetBodyWeight logs the user's body weight on a day, replacing the weight
+// already logged on it.
+func (h *Handler) SetBodyWeight(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling SetBodyWeight")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+	var request struct {
+		Weight float64 `json:"weight"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	if request.Weight <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid weight, must be positive"})
+		return
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	entry := storage.BodyWeight{UserID: user_ID, Date: date, Weight: unit.ToKg(request.Weight)}
+	if err := h.storage.AddBodyWeight(c.Request.Context(), entry); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	entry.Weight = unit.FromKg(entry.Weight)
+	c.JSON(200, entry)
+}
+
+func (h *Handler) DeleteBodyWeight(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteBodyWeight")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+	if err := h.storage.DeleteBodyWeight(c.Request.Context(), user_ID, date); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("body weight on %s deleted", date)})
+}
+
+// exerciseRequest is a custom exercise as the user enters it.
+type exerciseRequest struct {
+	Name            string                  `json:"name"`
+	Description     string                  `json:"description"`
+	MeasurementType storage.MeasurementType `json:"measurementType"`
+	Bodyweight      bool                    `json:"bodyweight"`
+	storage.ExerciseTaxonomy
+}
+
+const maxExerciseNameLength = 100
+
+// validateExercise checks a custom exercise and returns it as stored. The
+// taxonomy of request is normalized in place.
+func validateExercise(request *exerciseRequest) (storage.AllowedExercise, error) {
+	if err := validateTaxonomy(&request.ExerciseTaxonomy); err != nil {
+		return storage.AllowedExercise{}, err
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxExerciseNameLength {
+		return storage.AllowedExercise{}, fmt.Errorf("Invalid name, must be 1 to %d characters", maxExerciseNameLength)
+	}
+	if request.MeasurementType == "" {
+		request.MeasurementType = storage.MeasurementRepsWeight
+	}
+	if !request.MeasurementType.Valid() {
+		return storage.AllowedExercise{}, errors.New("Invalid measurementType, must be reps_weight, reps, time or distance_time")
+	}
+	return storage.AllowedExercise{
+		Name:            name,
+		Description:     strings.TrimSpace(request.Description),
+		MeasurementType: request.MeasurementType,
+		Bodyweight:      request.Bodyweight,
+	}, nil
+}
+
+func (h *Handler) LoadCustomExercises(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadCustomExercises")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	exercises, err := h.storage.GetCustomExercises(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"exercises": exercises})
+}
+
+func (h *Handler) CreateCustomExercise(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling CreateCustomExercise")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	var request exerciseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	exercise, err := validateExercise(&request)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var exerciseID int64
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		var err error
+		if exerciseID, err = tx.AddCustomExercise(c.Request.Context(), user_ID, exercise); err != nil {
+			return err
+		}
+		return tx.SetExerciseTaxonomy(c.Request.Context(), user_ID, exerciseID, request.ExerciseTaxonomy)
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetAllowedExercise(c.Request.Context(), exerciseID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(201, storage.CatalogExercise{AllowedExerciseInfo: created, ExerciseTaxonomy: request.ExerciseTaxonomy})
+}
+
+func (h *Handler) ChangeCustomExercise(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling ChangeCustomExercise")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	exerciseId, err := strconv.ParseInt(c.Param("exerciseId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	var request exerciseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	exercise, err := validateExercise(&request)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		if err := tx.UpdateCustomExercise(c.Request.Context(), user_ID, exerciseId, exercise); err != nil {
+			return err
+		}
+		return tx.SetExerciseTaxonomy(c.Request.Context(), user_ID, exerciseId, request.ExerciseTaxonomy)
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	updated, err := h.storage.GetAllowedExercise(c.Request.Context(), exerciseId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, storage.CatalogExercise{AllowedExerciseInfo: updated, ExerciseTaxonomy: request.ExerciseTaxonomy})
+}
+
+func (h *Handler) DeleteCustomExercise(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteCustomExercise")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	exerciseId, err := strconv.ParseInt(c.Param("exerciseId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid exerciseId, must be an integer"})
+		return
+	}
+	err = h.storage.DeleteCustomExercise(c.Request.Context(), user_ID, exerciseId)
+	if errors.Is(err, storage.ErrForeignKey) {
+		c.JSON(409, gin.H{"error": "Exercise is used in workouts or templates, remove it from them first"})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("exercise %d deleted", exerciseId)})
+}
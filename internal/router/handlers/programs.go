@@ -0,0 +1,560 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxProgramNameLength = 100
+	maxProgramWeeks      = 52
+)
+
+type programRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Weeks       []storage.ProgramWeek `json:"weeks"`
+}
+
+// checkProgram turns a request into a program of userID. Invalid input is
+// reported as a message for the client, storage failures as err; a template
+// of anyone else is an ErrForeignKey.
+func (h *Handler) checkProgram(ctx context.Context, userID int64, request programRequest) (program storage.Program, message string, err error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxProgramNameLength {
+		return program, fmt.Sprintf("Invalid name, must be 1 to %d characters", maxProgramNameLength), nil
+	}
+	if len(request.Weeks) == 0 || len(request.Weeks) > maxProgramWeeks {
+		return program, fmt.Sprintf("Invalid weeks, a program runs for 1 to %d weeks", maxProgramWeeks), nil
+	}
+	program = storage.Program{
+		UserID:      userID,
+		Name:        name,
+		Description: strings.TrimSpace(request.Description),
+	}
+	checked := make(map[int64]bool)
+	for i, week := range request.Weeks {
+		days := make(map[int]bool)
+		for _, day := range week.Days {
+			if day.Day < 1 || day.Day > 7 {
+				return program, fmt.Sprintf("Invalid day in week %d, must be between 1 and 7", i+1), nil
+			}
+			if days[day.Day] {
+				return program, fmt.Sprintf("Invalid week %d, day %d is listed twice", i+1, day.Day), nil
+			}
+			days[day.Day] = true
+			if !checked[day.TemplateID] {
+				_, err := h.storage.GetTemplate(ctx, userID, day.TemplateID)
+				if errors.Is(err, storage.ErrNotFound) {
+					err = fmt.Errorf("template %d: %w", day.TemplateID, storage.ErrForeignKey)
+				}
+				if err != nil {
+					return program, "", err
+				}
+				checked[day.TemplateID] = true
+			}
+		}
+		program.Weeks = append(program.Weeks, storage.ProgramWeek{Days: week.Days})
+	}
+	return program, "", nil
+}
+
+func (h *Handler) LoadPrograms(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadPrograms")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	programs, err := h.storage.GetPrograms(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"programs": programs})
+}
+
+func (h *Handler) LoadProgram(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadProgram")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	programId, err := strconv.ParseInt(c.Param("programId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid programId, must be an integer"})
+		return
+	}
+	program, err := h.storage.GetProgram(c.Request.Context(), user_ID, programId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, program)
+}
+
+func (h *Handler) CreateProgram(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling CreateProgram")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	var request programRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	program, message, err := h.checkProgram(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	programId, err := h.storage.AddProgram(c.Request.Context(), program)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetProgram(c.Request.Context(), user_ID, programId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(201, created)
+}
+
+// ChangeProgram replaces a program. Workouts already planned by enrollments
+// in it stay as they were.
+func (h *Handler) ChangeProgram(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling ChangeProgram")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	programId, err := strconv.ParseInt(c.Param("programId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid programId, must be an integer"})
+		return
+	}
+	var request programRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	program, message, err := h.checkProgram(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := h.storage.UpdateProgram(c.Request.Context(), user_ID, programId, program); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	updated, err := h.storage.GetProgram(c.Request.Context(), user_ID, programId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, updated)
+}
+
+// DeleteProgram deletes a program with its enrollments and planned workouts.
+func (h *Handler) DeleteProgram(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteProgram")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	programId, err := strconv.ParseInt(c.Param("programId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid programId, must be an integer"})
+		return
+	}
+	if err := h.storage.DeleteProgram(c.Request.Context(), user_ID, programId); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Program deleted"})
+}
+
+// EnrollInProgram enrolls the user in a program from startDate and plans its
+// workouts on the calendar. Every exercise with percentage loads in the
+// program's templates needs a training max, given in the user's unit.
+func (h *Handler) EnrollInProgram(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling EnrollInProgram")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	programId, err := strconv.ParseInt(c.Param("programId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid programId, must be an integer"})
+		return
+	}
+	var request struct {
+		StartDate     string                `json:"startDate"`
+		TrainingMaxes []storage.TrainingMax `json:"trainingMaxes"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	if !isValidDate(request.StartDate) {
+		c.JSON(400, gin.H{"error": "Invalid startDate, must be in YYYY-MM-DD format"})
+		return
+	}
+	program, err := h.storage.GetProgram(c.Request.Context(), user_ID, programId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	maxes := make(map[int64]bool)
+	trainingMaxes := []storage.TrainingMax{}
+	for _, tm := range request.TrainingMaxes {
+		if tm.Weight <= 0 {
+			c.JSON(400, gin.H{"error": "Invalid trainingMaxes, weights must be positive"})
+			return
+		}
+		if maxes[tm.ExerciseID] {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid trainingMaxes, exercise %d is listed twice", tm.ExerciseID)})
+			return
+		}
+		maxes[tm.ExerciseID] = true
+		trainingMaxes = append(trainingMaxes, storage.TrainingMax{ExerciseID: tm.ExerciseID, Weight: unit.ToKg(tm.Weight)})
+	}
+	templates := make(map[int64]bool)
+	for _, week := range program.Weeks {
+		for _, day := range week.Days {
+			if templates[day.TemplateID] {
+				continue
+			}
+			templates[day.TemplateID] = true
+			template, err := h.storage.GetTemplate(c.Request.Context(), user_ID, day.TemplateID)
+			if err != nil {
+				_ = c.Error(err)
+				return
+			}
+			for _, exercise := range template.Exercises {
+				for _, set := range exercise.Sets {
+					if set.PercentOfTrainingMax != nil && !maxes[exercise.ExerciseID] {
+						c.JSON(400, gin.H{"error": fmt.Sprintf("Missing training max for exercise %d", exercise.ExerciseID)})
+						return
+					}
+				}
+			}
+		}
+	}
+	enrollmentId, err := h.storage.AddEnrollment(c.Request.Context(), storage.Enrollment{
+		UserID:        user_ID,
+		ProgramID:     programId,
+		StartDate:     request.StartDate,
+		TrainingMaxes: trainingMaxes,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	enrollment, err := h.storage.GetEnrollment(c.Request.Context(), user_ID, enrollmentId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	showEnrollment(unit, enrollment)
+	c.JSON(201, enrollment)
+}
+
+// showEnrollment converts the training maxes of an enrollment to unit.
+func showEnrollment(unit storage.WeightUnit, enrollment *storage.Enrollment) {
+	for i := range enrollment.TrainingMaxes {
+		enrollment.TrainingMaxes[i].Weight = unit.FromKg(enrollment.TrainingMaxes[i].Weight)
+	}
+}
+
+func (h *Handler) LoadEnrollments(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadEnrollments")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	enrollments, err := h.storage.GetEnrollments(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	for i := range enrollments {
+		showEnrollment(unit, &enrollments[i])
+	}
+	c.JSON(200, gin.H{"enrollments": enrollments})
+}
+
+// DeleteEnrollment leaves a program. Its planned workouts are removed from
+// the calendar; logged workouts stay.
+func (h *Handler) DeleteEnrollment(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteEnrollment")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	enrollmentId, err := strconv.ParseInt(c.Param("enrollmentId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid enrollmentId, must be an integer"})
+		return
+	}
+	if err := h.storage.DeleteEnrollment(c.Request.Context(), user_ID, enrollmentId); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Enrollment deleted"})
+}
+
+// LoadPlannedWorkouts lists the workouts planned between the optional from
+// and to dates, done or not.
+func (h *Handler) LoadPlannedWorkouts(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadPlannedWorkouts")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	from := c.DefaultQuery("from", calendarMinDate)
+	to := c.DefaultQuery("to", calendarMaxDate)
+	if !isValidDate(from) || !isValidDate(to) || from > to {
+		logger.Error("invalid date range", "from", from, "to", to)
+		c.JSON(400, gin.H{"error": "from and to must be dates in YYYY-MM-DD format, from not after to"})
+		return
+	}
+	plans, err := h.storage.GetPlannedWorkouts(c.Request.Context(), user_ID, from, to)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"plannedWorkouts": plans})
+}
+
+// LoadPlannedWorkout shows a planned workout with the exercises and sets of
+// its template, percentage loads worked out from the training maxes.
+func (h *Handler) LoadPlannedWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadPlannedWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	plannedWorkoutId, err := strconv.ParseInt(c.Param("plannedWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid plannedWorkoutId, must be an integer"})
+		return
+	}
+	plan, template, err := h.plannedTemplate(c.Request.Context(), h.storage, user_ID, plannedWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	templates := []storage.WorkoutTemplate{*template}
+	if err := h.showTemplates(c, user_ID, templates); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, struct {
+		*storage.PlannedWorkout
+		Exercises []storage.TemplateExercise `json:"exercises"`
+	}{plan, templates[0].Exercises})
+}
+
+// plannedTemplate reads a planned workout and its template with the loads
+// resolved.
+func (h *Handler) plannedTemplate(ctx context.Context, repos storage.Repos, userID, plannedWorkoutID int64) (*storage.PlannedWorkout, *storage.WorkoutTemplate, error) {
+	plan, err := repos.GetPlannedWorkout(ctx, userID, plannedWorkoutID)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := repos.GetTemplate(ctx, userID, plan.TemplateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	enrollment, err := repos.GetEnrollment(ctx, userID, plan.EnrollmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.ResolveLoads(enrollment.TrainingMaxes)
+	return plan, template, nil
+}
+
+// errPlanDone is returned inside StartPlannedWorkout's transaction when the
+// plan was already logged, so it is told apart from other conflicts.
+var errPlanDone = errors.New("planned workout is already done")
+
+// StartPlannedWorkout logs a planned workout on its date from its template,
+// with percentage loads worked out, and marks the plan done. The body is as
+// for CreateTrainingFromTemplate.
+func (h *Handler) StartPlannedWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling StartPlannedWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	plannedWorkoutId, err := strconv.ParseInt(c.Param("plannedWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid plannedWorkoutId, must be an integer"})
+		return
+	}
+	request, message := bindStartRequest(c)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	var workoutId int64
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		plan, template, err := h.plannedTemplate(c.Request.Context(), tx, user_ID, plannedWorkoutId)
+		if err != nil {
+			return err
+		}
+		if plan.Completed {
+			return errPlanDone
+		}
+		if workoutId, err = addWorkoutFromTemplate(c.Request.Context(), tx, plan.Date, template, request); err != nil {
+			return err
+		}
+		return tx.LinkPlannedWorkout(c.Request.Context(), plannedWorkoutId, workoutId)
+	})
+	if errors.Is(err, errPlanDone) {
+		c.JSON(409, gin.H{"error": "Planned workout is already done"})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag(created.Version))
+	c.JSON(201, created)
+}
@@ -0,0 +1,568 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"diaryserver/internal/rrule"
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRecurringTitleLength = 100
+
+type recurringRequest struct {
+	Title      string `json:"title"`
+	Notes      string `json:"notes"`
+	StartDate  string `json:"startDate"`
+	Time       string `json:"time"`
+	RRule      string `json:"rrule"`
+	TemplateID *int64 `json:"templateId"`
+}
+
+// checkRecurring turns a request into a series of userID with its rule in
+// canonical form. Invalid input is reported as a message for the client,
+// storage failures as err; a template of anyone else is an ErrForeignKey.
+func (h *Handler) checkRecurring(ctx context.Context, userID int64, request recurringRequest) (workout storage.RecurringWorkout, message string, err error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" || utf8.RuneCountInString(title) > maxRecurringTitleLength {
+		return workout, fmt.Sprintf("Invalid title, must be 1 to %d characters", maxRecurringTitleLength), nil
+	}
+	if !isValidDate(request.StartDate) {
+		return workout, "Invalid startDate, must be in YYYY-MM-DD format", nil
+	}
+	startTime := ""
+	if request.Time != "" {
+		normalized, ok := normalizeTime(request.Time)
+		if !ok {
+			return workout, "Invalid time, must be in HH:MM or HH:MM:SS format", nil
+		}
+		startTime = normalized
+	}
+	rule, err := rrule.Parse(request.RRule)
+	if err != nil {
+		return workout, fmt.Sprintf("Invalid rrule: %v", err), nil
+	}
+	if request.TemplateID != nil {
+		_, err := h.storage.GetTemplate(ctx, userID, *request.TemplateID)
+		if errors.Is(err, storage.ErrNotFound) {
+			err = fmt.Errorf("template %d: %w", *request.TemplateID, storage.ErrForeignKey)
+		}
+		if err != nil {
+			return workout, "", err
+		}
+	}
+	return storage.RecurringWorkout{
+		UserID:     userID,
+		Title:      title,
+		Notes:      strings.TrimSpace(request.Notes),
+		StartDate:  request.StartDate,
+		Time:       startTime,
+		RRule:      rule.String(),
+		TemplateID: request.TemplateID,
+	}, "", nil
+}
+
+// plannedEntry is a workout planned on the calendar: an occurrence of a
+// recurring workout or a session of a program enrollment. Status is
+// "planned" until a workout is logged for it, then "done".
+type plannedEntry struct {
+	Kind               string `json:"kind"`
+	RecurringWorkoutID int64  `json:"recurringWorkoutId,omitempty"`
+	OccurrenceDate     string `json:"occurrenceDate,omitempty"`
+	Moved              bool   `json:"moved,omitempty"`
+	PlannedWorkoutID   int64  `json:"plannedWorkoutId,omitempty"`
+	Title              string `json:"title"`
+	Date               string `json:"-"`
+	Time               string `json:"time"`
+	Status             string `json:"status"`
+	WorkoutID          *int64 `json:"workoutId"`
+}
+
+func plannedStatus(completed bool) string {
+	if completed {
+		return "done"
+	}
+	return "planned"
+}
+
+// plannedEntries returns the planned workouts of userID between from and to,
+// in order of date and time, on the first limit days that have any. Like
+// GetCalendarDays it counts days, not entries, so the two can be merged into
+// a page of limit days.
+func (h *Handler) plannedEntries(ctx context.Context, userID int64, from, to string, limit int) ([]plannedEntry, error) {
+	entries := []plannedEntry{}
+	series, err := h.storage.GetRecurringWorkouts(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, workout := range series {
+		occurrences, err := workout.Occurrences(from, to, limit)
+		if err != nil {
+			return nil, fmt.Errorf("recurring workout %d: %w", workout.RecurringWorkoutID, err)
+		}
+		for _, o := range occurrences {
+			entries = append(entries, plannedEntry{
+				Kind:               "recurring",
+				RecurringWorkoutID: o.RecurringWorkoutID,
+				OccurrenceDate:     o.OccurrenceDate,
+				Moved:              o.Moved,
+				Title:              o.Title,
+				Date:               o.Date,
+				Time:               o.Time,
+				Status:             plannedStatus(o.Completed),
+				WorkoutID:          o.WorkoutID,
+			})
+		}
+	}
+	plans, err := h.storage.GetPlannedWorkouts(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range plans {
+		entries = append(entries, plannedEntry{
+			Kind:             "program",
+			PlannedWorkoutID: plan.PlannedWorkoutID,
+			Title:            fmt.Sprintf("%s: %s", plan.ProgramName, plan.TemplateName),
+			Date:             plan.Date,
+			Status:           plannedStatus(plan.Completed),
+			WorkoutID:        plan.WorkoutID,
+		})
+	}
+	slices.SortStableFunc(entries, func(a, b plannedEntry) int {
+		return cmp.Or(cmp.Compare(a.Date, b.Date), cmp.Compare(a.Time, b.Time))
+	})
+	days := 0
+	for i, entry := range entries {
+		if i == 0 || entry.Date != entries[i-1].Date {
+			if days++; days > limit {
+				return entries[:i], nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+// findOccurrence returns what was done to the occurrence the rule of w puts
+// on date, and false if the rule puts none there.
+func findOccurrence(w *storage.RecurringWorkout, date string) (storage.RecurrenceException, bool) {
+	exception := storage.RecurrenceException{Date: date}
+	rule, err := rrule.Parse(w.RRule)
+	if err != nil {
+		return exception, false
+	}
+	start, err := time.Parse(dateLayout, w.StartDate)
+	if err != nil {
+		return exception, false
+	}
+	day, err := time.Parse(dateLayout, date)
+	if err != nil || !rule.Occurs(start, day) {
+		return exception, false
+	}
+	for _, e := range w.Exceptions {
+		if e.Date == date {
+			return e, true
+		}
+	}
+	return exception, true
+}
+
+func (h *Handler) LoadRecurringWorkouts(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadRecurringWorkouts")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	workouts, err := h.storage.GetRecurringWorkouts(c.Request.Context(), user_ID, calendarMinDate, calendarMaxDate)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"recurringWorkouts": workouts})
+}
+
+func (h *Handler) LoadRecurringWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadRecurringWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	workout, err := h.storage.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, workout)
+}
+
+// CreateRecurringWorkout plans a series of workouts, e.g. Mon/Wed/Fri 07:00
+// as startDate, time "07:00" and rrule "FREQ=WEEKLY;BYDAY=MO,WE,FR".
+func (h *Handler) CreateRecurringWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling CreateRecurringWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	var request recurringRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	workout, message, err := h.checkRecurring(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	recurringWorkoutId, err := h.storage.AddRecurringWorkout(c.Request.Context(), workout)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(201, created)
+}
+
+// ChangeRecurringWorkout replaces a series. Skipped and moved occurrences
+// go back to where the new rule puts them.
+func (h *Handler) ChangeRecurringWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling ChangeRecurringWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	var request recurringRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	workout, message, err := h.checkRecurring(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := h.storage.UpdateRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId, workout); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	updated, err := h.storage.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, updated)
+}
+
+// DeleteRecurringWorkout ends a series. Workouts converted from its
+// occurrences stay.
+func (h *Handler) DeleteRecurringWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteRecurringWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	if err := h.storage.DeleteRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Recurring workout deleted"})
+}
+
+// SetRecurrenceException skips the occurrence the rule puts on date, with
+// {"action": "skip"}, or moves it, with {"action": "move", "date": ...} and
+// an optional time that defaults to the series'.
+func (h *Handler) SetRecurrenceException(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling SetRecurrenceException")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "Invalid date, must be in YYYY-MM-DD format"})
+		return
+	}
+	var request struct {
+		Action storage.RecurrenceAction `json:"action"`
+		Date   string                   `json:"date"`
+		Time   string                   `json:"time"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	workout, err := h.storage.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	exception, ok := findOccurrence(workout, date)
+	if !ok {
+		c.JSON(404, gin.H{"error": "No occurrence on this date"})
+		return
+	}
+	if exception.WorkoutID != nil {
+		c.JSON(409, gin.H{"error": "Occurrence is already done"})
+		return
+	}
+	exception = storage.RecurrenceException{Date: date, Action: request.Action}
+	switch request.Action {
+	case storage.RecurrenceSkip:
+	case storage.RecurrenceMove:
+		if !isValidDate(request.Date) {
+			c.JSON(400, gin.H{"error": "Invalid date to move to, must be in YYYY-MM-DD format"})
+			return
+		}
+		exception.MovedDate, exception.MovedTime = request.Date, workout.Time
+		if request.Time != "" {
+			normalized, ok := normalizeTime(request.Time)
+			if !ok {
+				c.JSON(400, gin.H{"error": "Invalid time, must be in HH:MM or HH:MM:SS format"})
+				return
+			}
+			exception.MovedTime = normalized
+		}
+	default:
+		c.JSON(400, gin.H{"error": "Invalid action, must be skip or move"})
+		return
+	}
+	if err := h.storage.SetRecurrenceException(c.Request.Context(), user_ID, recurringWorkoutId, exception); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, exception)
+}
+
+// DeleteRecurrenceException puts a skipped or moved occurrence back on the
+// date the rule gives it.
+func (h *Handler) DeleteRecurrenceException(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteRecurrenceException")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "Invalid date, must be in YYYY-MM-DD format"})
+		return
+	}
+	workout, err := h.storage.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if exception, _ := findOccurrence(workout, date); exception.WorkoutID != nil {
+		c.JSON(409, gin.H{"error": "Occurrence is already done"})
+		return
+	}
+	if err := h.storage.DeleteRecurrenceException(c.Request.Context(), user_ID, recurringWorkoutId, date); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Exception deleted"})
+}
+
+// StartOccurrence converts the occurrence the rule puts on date into a
+// workout on the date and at the time it is planned for, from the series'
+// template if it has one. The body is as for CreateTrainingFromTemplate;
+// startTime defaults to the planned time and notes to the series'.
+func (h *Handler) StartOccurrence(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling StartOccurrence")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	recurringWorkoutId, err := strconv.ParseInt(c.Param("recurringWorkoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid recurringWorkoutId, must be an integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "Invalid date, must be in YYYY-MM-DD format"})
+		return
+	}
+	request, message := bindStartRequest(c)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	var workoutId int64
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		workout, err := tx.GetRecurringWorkout(c.Request.Context(), user_ID, recurringWorkoutId)
+		if err != nil {
+			return err
+		}
+		exception, ok := findOccurrence(workout, date)
+		switch {
+		case !ok:
+			message = "No occurrence on this date"
+			return fmt.Errorf("recurring workout %d on %s: %w", recurringWorkoutId, date, storage.ErrNotFound)
+		case exception.WorkoutID != nil:
+			message = "Occurrence is already done"
+			return fmt.Errorf("recurring workout %d on %s is done: %w", recurringWorkoutId, date, storage.ErrConflict)
+		case exception.Action == storage.RecurrenceSkip:
+			message = "Occurrence is skipped, restore it first"
+			return fmt.Errorf("recurring workout %d on %s is skipped: %w", recurringWorkoutId, date, storage.ErrConflict)
+		}
+		plannedDate, plannedTime := date, workout.Time
+		if exception.Action == storage.RecurrenceMove {
+			plannedDate, plannedTime = exception.MovedDate, exception.MovedTime
+		}
+		if request.StartTime == "" {
+			request.StartTime = plannedTime
+		}
+		if request.Notes == nil && workout.Notes != "" {
+			request.Notes = &workout.Notes
+		}
+		template := &storage.WorkoutTemplate{UserID: user_ID}
+		if workout.TemplateID != nil {
+			if template, err = tx.GetTemplate(c.Request.Context(), user_ID, *workout.TemplateID); err != nil {
+				return err
+			}
+		}
+		if workoutId, err = addWorkoutFromTemplate(c.Request.Context(), tx, plannedDate, template, request); err != nil {
+			return err
+		}
+		return tx.LinkRecurrenceWorkout(c.Request.Context(), recurringWorkoutId, date, workoutId)
+	})
+	if message != "" {
+		code := 409
+		if errors.Is(err, storage.ErrNotFound) {
+			code = 404
+		}
+		c.JSON(code, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag(created.Version))
+	c.JSON(201, created)
+}
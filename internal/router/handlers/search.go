@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"diaryserver/internal/storage"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+)
+
+// highlightReplacer turns the storage highlight markers into <mark> tags once
+// the snippet has been HTML-escaped.
+var highlightReplacer = strings.NewReplacer(
+	storage.HighlightStart, "<mark>",
+	storage.HighlightEnd, "</mark>",
+)
+
+func highlight(snippet string) string {
+	return highlightReplacer.Replace(html.EscapeString(snippet))
+}
+
+func (h *Handler) Search(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling Search")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	filter := storage.SearchFilter{
+		Query: strings.TrimSpace(c.Query("q")),
+		From:  c.Query("from"),
+		To:    c.Query("to"),
+		Limit: searchDefaultLimit,
+	}
+	if len(storage.SearchTerms(filter.Query)) == 0 {
+		c.JSON(400, gin.H{"error": "q must contain at least one word"})
+		return
+	}
+	if (filter.From != "" && !isValidDate(filter.From)) || (filter.To != "" && !isValidDate(filter.To)) {
+		c.JSON(400, gin.H{"error": "from and to must be dates in YYYY-MM-DD format"})
+		return
+	}
+	if exerciseStr := c.Query("exercise"); exerciseStr != "" {
+		exerciseID, err := strconv.ParseInt(exerciseStr, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid exercise, must be an integer"})
+			return
+		}
+		filter.ExerciseID = exerciseID
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > searchMaxLimit {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid limit, must be an integer between 1 and %d", searchMaxLimit)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	results, err := h.storage.SearchWorkouts(c.Request.Context(), user_ID, filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	type Result struct {
+		WorkoutId        int64   `json:"workoutId"`
+		Date             string  `json:"date"`
+		StartTime        string  `json:"timeStart"`
+		EndTime          string  `json:"timeEnd"`
+		Version          int64   `json:"version"`
+		NotesSnippet     string  `json:"notesSnippet"`
+		ExercisesSnippet string  `json:"exercisesSnippet"`
+		Rank             float64 `json:"rank"`
+	}
+	response := struct {
+		Results []Result `json:"results"`
+	}{Results: []Result{}}
+	for _, result := range results {
+		response.Results = append(response.Results, Result{
+			WorkoutId:        result.WorkoutID,
+			Date:             result.Date,
+			StartTime:        result.StartTime,
+			EndTime:          result.EndTime,
+			Version:          result.Version,
+			NotesSnippet:     highlight(result.NotesSnippet),
+			ExercisesSnippet: highlight(result.ExercisesSnippet),
+			Rank:             result.Rank,
+		})
+	}
+	c.JSON(200, response)
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"diaryserver/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxTemplateNameLength   = 100
+	maxPercentOfTrainingMax = 200
+)
+
+type templateRequest struct {
+	Name      string                     `json:"name"`
+	Notes     string                     `json:"notes"`
+	Exercises []storage.TemplateExercise `json:"exercises"`
+}
+
+// checkTemplate turns a request into a template of userID with weights in
+// kilograms. Invalid input is reported as a message for the client, storage
+// failures as err; an exercise the user cannot see is an ErrForeignKey.
+func (h *Handler) checkTemplate(ctx context.Context, userID int64, request templateRequest) (template storage.WorkoutTemplate, message string, err error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTemplateNameLength {
+		return template, fmt.Sprintf("Invalid name, must be 1 to %d characters", maxTemplateNameLength), nil
+	}
+	unit, err := h.storage.GetWeightUnit(ctx, userID)
+	if err != nil {
+		return template, "", err
+	}
+	template = storage.WorkoutTemplate{
+		UserID:    userID,
+		Name:      name,
+		Notes:     strings.TrimSpace(request.Notes),
+		Exercises: []storage.TemplateExercise{},
+	}
+	for _, requested := range request.Exercises {
+		exercise, err := h.storage.GetAllowedExercise(ctx, requested.ExerciseID)
+		if err == nil && !exercise.VisibleTo(userID) {
+			err = storage.ErrNotFound
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			err = fmt.Errorf("allowed exercise %d: %w", requested.ExerciseID, storage.ErrForeignKey)
+		}
+		if err != nil {
+			return template, "", err
+		}
+		sets := []storage.TemplateSet{}
+		for _, set := range requested.Sets {
+			// A percentage load takes the place of the weight.
+			if set.PercentOfTrainingMax != nil {
+				if p := *set.PercentOfTrainingMax; p <= 0 || p > maxPercentOfTrainingMax {
+					return template, fmt.Sprintf("Invalid percentOfTrainingMax, must be above 0 and at most %d", maxPercentOfTrainingMax), nil
+				}
+				if exercise.MeasurementType != storage.MeasurementRepsWeight {
+					return template, "Invalid percentOfTrainingMax, the exercise records no weight", nil
+				}
+				set.Weight = 0
+			}
+			if err := validateSet(exercise, set.Repetitions, set.Weight, &set.SetDetails); err != nil {
+				return template, err.Error(), nil
+			}
+			set.Weight = unit.ToKg(set.Weight)
+			sets = append(sets, set)
+		}
+		template.Exercises = append(template.Exercises, storage.TemplateExercise{ExerciseID: requested.ExerciseID, Sets: sets})
+	}
+	return template, "", nil
+}
+
+// showTemplates converts the weights of templates to the user's unit and
+// translates their exercise names, in place.
+func (h *Handler) showTemplates(c *gin.Context, userID int64, templates []storage.WorkoutTemplate) error {
+	unit, err := h.storage.GetWeightUnit(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+	locale, err := h.locale(c, userID)
+	if err != nil {
+		return err
+	}
+	translations, err := h.exerciseTranslations(c.Request.Context(), locale)
+	if err != nil {
+		return err
+	}
+	for i := range templates {
+		for j := range templates[i].Exercises {
+			exercise := &templates[i].Exercises[j]
+			exercise.ExerciseName = exerciseName(exercise.ExerciseID, exercise.ExerciseName, translations)
+			for k := range exercise.Sets {
+				exercise.Sets[k].Weight = unit.FromKg(exercise.Sets[k].Weight)
+			}
+		}
+	}
+	return nil
+}
+
+func (h *Handler) LoadTemplates(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadTemplates")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	templates, err := h.storage.GetTemplates(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := h.showTemplates(c, user_ID, templates); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"templates": templates})
+}
+
+func (h *Handler) LoadTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	templateId, err := strconv.ParseInt(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid templateId, must be an integer"})
+		return
+	}
+	h.respondTemplate(c, 200, user_ID, templateId)
+}
+
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling CreateTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	var request templateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	template, message, err := h.checkTemplate(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	templateId, err := h.storage.AddTemplate(c.Request.Context(), template)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	h.respondTemplate(c, 201, user_ID, templateId)
+}
+
+func (h *Handler) ChangeTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling ChangeTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	templateId, err := strconv.ParseInt(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid templateId, must be an integer"})
+		return
+	}
+	var request templateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	template, message, err := h.checkTemplate(c.Request.Context(), user_ID, request)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := h.storage.UpdateTemplate(c.Request.Context(), user_ID, templateId, template); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	h.respondTemplate(c, 200, user_ID, templateId)
+}
+
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling DeleteTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	templateId, err := strconv.ParseInt(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid templateId, must be an integer"})
+		return
+	}
+	err = h.storage.DeleteTemplate(c.Request.Context(), user_ID, templateId)
+	if errors.Is(err, storage.ErrForeignKey) {
+		c.JSON(409, gin.H{"error": "Template is used by programs, remove it from them first"})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"message": "Template deleted"})
+}
+
+// respondTemplate answers with a template as it was stored.
+func (h *Handler) respondTemplate(c *gin.Context, status int, userID, templateID int64) {
+	template, err := h.storage.GetTemplate(c.Request.Context(), userID, templateID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	templates := []storage.WorkoutTemplate{*template}
+	if err := h.showTemplates(c, userID, templates); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(status, templates[0])
+}
+
+// startRequest is the optional body of requests that start a workout from a
+// template.
+type startRequest struct {
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+	Notes     *string `json:"notes"`
+}
+
+// bindStartRequest reads a startRequest, normalizing its times. A message is
+// returned for invalid input.
+func bindStartRequest(c *gin.Context) (startRequest, string) {
+	var request startRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		return request, "invalid request body"
+	}
+	for _, value := range []*string{&request.StartTime, &request.EndTime} {
+		if *value == "" {
+			continue
+		}
+		normalized, ok := normalizeTime(*value)
+		if !ok {
+			return request, "Invalid time, must be in HH:MM or HH:MM:SS format"
+		}
+		*value = normalized
+	}
+	return request, ""
+}
+
+// addWorkoutFromTemplate adds a workout of the template's user on date with
+// the template's exercises and sets.
+func addWorkoutFromTemplate(ctx context.Context, tx storage.Repos, date string, template *storage.WorkoutTemplate, request startRequest) (int64, error) {
+	notes := template.Notes
+	if request.Notes != nil {
+		notes = *request.Notes
+	}
+	workoutId, err := tx.AddWorkout(ctx, storage.Workout{
+		UserID:    template.UserID,
+		Date:      date,
+		StartTime: request.StartTime,
+		EndTime:   request.EndTime,
+		Notes:     notes,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, exercise := range template.Exercises {
+		workoutExerciseId, err := tx.AddWorkoutExercise(ctx, storage.WorkoutExercise{
+			WorkoutID:  workoutId,
+			ExerciseID: exercise.ExerciseID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if len(exercise.Sets) == 0 {
+			continue
+		}
+		var sets []storage.Set
+		for _, set := range exercise.Sets {
+			sets = append(sets, storage.Set{WorkoutExerciseID: workoutExerciseId, Repetitions: set.Repetitions, Weight: set.Weight, SetDetails: set.SetDetails})
+		}
+		if err := tx.AddSets(ctx, sets); err != nil {
+			return 0, err
+		}
+	}
+	return workoutId, nil
+}
+
+// CreateTrainingFromTemplate starts a workout on date with the exercises and
+// target sets of a template, all in one transaction. The body is optional
+// and may set startTime, endTime and notes; notes default to the template's.
+// Percentage loads need a program's training maxes, so their sets start
+// without weight here; see StartPlannedWorkout.
+func (h *Handler) CreateTrainingFromTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling CreateTrainingFromTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	date := c.Param("date")
+	if !isValidDate(date) {
+		c.JSON(400, gin.H{"error": "Invalid date, must be in YYYY-MM-DD format"})
+		return
+	}
+	templateId, err := strconv.ParseInt(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid templateId, must be an integer"})
+		return
+	}
+	request, message := bindStartRequest(c)
+	if message != "" {
+		c.JSON(400, gin.H{"error": message})
+		return
+	}
+	var workoutId int64
+	err = h.storage.WithTx(c.Request.Context(), func(tx storage.Repos) error {
+		template, err := tx.GetTemplate(c.Request.Context(), user_ID, templateId)
+		if err != nil {
+			return err
+		}
+		workoutId, err = addWorkoutFromTemplate(c.Request.Context(), tx, date, template, request)
+		return err
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	created, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag(created.Version))
+	c.JSON(201, created)
+}
+
+// SaveWorkoutAsTemplate creates a template from the exercises and sets of a
+// workout, under the name in the body.
+func (h *Handler) SaveWorkoutAsTemplate(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling SaveWorkoutAsTemplate")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	workoutId, err := strconv.ParseInt(c.Param("workoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
+		return
+	}
+	var request struct {
+		Name  string `json:"name"`
+		Notes string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error("invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTemplateNameLength {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid name, must be 1 to %d characters", maxTemplateNameLength)})
+		return
+	}
+	workoutInfo, err := h.storage.GetWorkoutDetails(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if workoutInfo.UserID != user_ID {
+		logger.Error("Access Denied")
+		c.JSON(403, gin.H{"error": "Access Denied"})
+		return
+	}
+	template := storage.WorkoutTemplate{
+		UserID: user_ID,
+		Name:   name,
+		Notes:  strings.TrimSpace(request.Notes),
+	}
+	for _, exercise := range workoutInfo.Exercises {
+		var sets []storage.TemplateSet
+		for _, set := range exercise.Sets {
+			sets = append(sets, storage.TemplateSet{Repetitions: set.Repetitions, Weight: set.Weight, SetDetails: set.SetDetails})
+		}
+		template.Exercises = append(template.Exercises, storage.TemplateExercise{ExerciseID: exercise.ExerciseID, Sets: sets})
+	}
+	templateId, err := h.storage.AddTemplate(c.Request.Context(), template)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	h.respondTemplate(c, 201, user_ID, templateId)
+}
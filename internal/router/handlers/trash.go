@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) LoadTrash(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling LoadTrash")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	workouts, err := h.storage.GetDeletedWorkouts(c.Request.Context(), user_ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"workouts": workouts})
+}
+
+func (h *Handler) RestoreWorkout(c *gin.Context) {
+	logger := c.MustGet("logger").(*slog.Logger)
+	logger.Debug("handling RestoreWorkout")
+	user_ID_Object, successful := c.Get("user_id")
+	if !successful {
+		logger.Error("User id not found")
+		c.JSON(400, gin.H{"error": "User id not found"})
+		return
+	}
+	user_ID, ok := user_ID_Object.(int64)
+	if !ok {
+		logger.Error("User id is not integer")
+		c.JSON(400, gin.H{"error": "User id is not integer"})
+		return
+	}
+	workoutId, err := strconv.ParseInt(c.Param("workoutId"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid workoutId, must be an integer"})
+		return
+	}
+	trashed, err := h.storage.GetDeletedWorkout(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if trashed.UserID != user_ID {
+		logger.Error("Access Denied")
+		c.JSON(403, gin.H{"error": "Access Denied"})
+		return
+	}
+	if err := h.storage.RestoreWorkout(c.Request.Context(), workoutId); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	restored, err := h.storage.GetWorkoutFromID(c.Request.Context(), workoutId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Header("ETag", etag(restored.Version))
+	c.JSON(200, restored)
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,10 +19,9 @@ func (h *Handler) GetUser(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "username is required"})
 		return
 	}
-	user, err := h.storage.GetUser(username)
+	user, err := h.storage.GetUser(c.Request.Context(), username)
 	if err != nil {
-		logger.Error("failed to get user", "error", err)
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -32,10 +31,9 @@ func (h *Handler) GetUsers(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
 	logger.Debug("handling users requeset")
 
-	users, err := h.storage.GetUsers()
+	users, err := h.storage.GetUsers(c.Request.Context())
 	if err != nil {
-		logger.Error("failed to get users", "error", err)
-		c.JSON(500, gin.H{"error": "failed to get users"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -46,16 +44,15 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
 	logger.Debug("handling create user request")
 
-	var user sqlite.User
+	var user storage.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		logger.Error("invalid request body", "error", err)
 		c.JSON(400, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	if err := h.storage.AddUser(user); err != nil {
-		logger.Error("failed to create user", "error", err)
-		c.JSON(500, gin.H{"error": "failed to create user"})
+	if err := h.storage.AddUser(c.Request.Context(), user); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -66,7 +63,7 @@ func (h *Handler) CreateUsers(c *gin.Context) {
 	logger.Debug("handling create users requeset")
 
 	var request struct {
-		Users []sqlite.User `json:"users"`
+		Users []storage.User `json:"users"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		logger.Error("invalid request body", "error", err)
@@ -74,9 +71,8 @@ func (h *Handler) CreateUsers(c *gin.Context) {
 		return
 	}
 
-	if err := h.storage.AddUsers(request.Users); err != nil {
-		logger.Error("failed to create users", "error", err)
-		c.JSON(500, gin.H{"error": "failed to create users"})
+	if err := h.storage.AddUsers(c.Request.Context(), request.Users); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -86,10 +82,9 @@ func (h *Handler) DeleteAllUsers(c *gin.Context) {
 	logger := c.MustGet("logger").(*slog.Logger)
 	logger.Debug("handling delete all users requeset")
 
-	err := h.storage.DeleteAllUsers()
+	err := h.storage.DeleteAllUsers(c.Request.Context())
 	if err != nil {
-		logger.Error("failed to delete all users", "error", err)
-		c.JSON(500, gin.H{"error": "failed to delete all users"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -106,10 +101,9 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err := h.storage.DeleteUser(username)
+	err := h.storage.DeleteUser(c.Request.Context(), username)
 	if err != nil {
-		logger.Error("failed to delete user", "error", err)
-		c.JSON(500, gin.H{"error": "failed to delete user"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"diaryserver/internal/config"
+	"diaryserver/internal/storage"
+	"errors"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin lets through only the users named in cfg.Admin. It must run
+// after AuthMiddleware, which sets user_id.
+func RequireAdmin(db storage.Storage, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*slog.Logger)
+		userIDObject, ok := c.Get("user_id")
+		if !ok {
+			logger.Error("User id not found")
+			c.JSON(400, gin.H{"error": "User id not found"})
+			c.Abort()
+			return
+		}
+		userID, ok := userIDObject.(int64)
+		if !ok {
+			logger.Error("User id is not integer")
+			c.JSON(400, gin.H{"error": "User id is not integer"})
+			c.Abort()
+			return
+		}
+		for _, username := range cfg.Admin.Usernames {
+			user, err := db.GetUser(c.Request.Context(), username)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				_ = c.Error(err)
+				c.Abort()
+				return
+			}
+			if user.UserID == userID {
+				c.Next()
+				return
+			}
+		}
+		logger.Warn("admin access denied", "user_id", userID)
+		c.JSON(403, gin.H{"error": "Access Denied"})
+		c.Abort()
+	}
+}
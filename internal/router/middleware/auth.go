@@ -3,7 +3,7 @@ package middleware
 import (
 	"diaryserver/internal/config"
 	"diaryserver/internal/service"
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -11,7 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(storage *sqlite.Storage, cfg *config.Config) gin.HandlerFunc {
+func AuthMiddleware(storage storage.Storage, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := c.MustGet("logger").(*slog.Logger)
 		logger.Debug("checking authentication")
@@ -33,10 +33,9 @@ func AuthMiddleware(storage *sqlite.Storage, cfg *config.Config) gin.HandlerFunc
 				return
 			}
 		} else if accessToken != " " {
-			isBlacklisted, err := storage.IsTokenBlacklisted(accessToken)
+			isBlacklisted, err := storage.IsTokenBlacklisted(c.Request.Context(), accessToken)
 			if err != nil {
-				logger.Error("failed to check token blacklist", "error", err)
-				c.JSON(500, gin.H{"error": "internal server error"})
+				_ = c.Error(err)
 				c.Abort()
 				return
 			}
@@ -63,10 +62,9 @@ func AuthMiddleware(storage *sqlite.Storage, cfg *config.Config) gin.HandlerFunc
 			c.Abort()
 			return
 		}
-		isBlacklisted, err := storage.IsTokenBlacklisted(refreshToken)
+		isBlacklisted, err := storage.IsTokenBlacklisted(c.Request.Context(), refreshToken)
 		if err != nil {
-			logger.Error("failed to check token blacklist", "error", err)
-			c.JSON(500, gin.H{"error": "internal server error"})
+			_ = c.Error(err)
 			c.Abort()
 			return
 		}
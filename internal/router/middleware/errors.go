@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"diaryserver/internal/storage"
+	"errors"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusClientClosedRequest is the nginx convention for a client that went
+// away before the response was ready.
+const statusClientClosedRequest = 499
+
+// ErrorHandler answers requests whose handlers recorded an error with c.Error
+// and did not write a response themselves. Storage and context errors are
+// mapped to their HTTP statuses here so that every handler reports them alike.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		logger := c.MustGet("logger").(*slog.Logger)
+		err := c.Errors.Last().Err
+		status, message := errorStatus(err)
+		if status >= 500 {
+			logger.Error("request failed", "status", status, "error", err)
+		} else {
+			logger.Warn("request failed", "status", status, "error", err)
+		}
+		c.JSON(status, gin.H{"error": message})
+	}
+}
+
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return 404, "Not found"
+	case errors.Is(err, storage.ErrConflict):
+		return 409, "Already exists"
+	case errors.Is(err, storage.ErrForeignKey):
+		return 422, "Referenced resource does not exist"
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return 412, "Precondition failed"
+	case errors.Is(err, storage.ErrSearchUnavailable):
+		return 501, "Search is not available on this server"
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, "Request cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return 503, "Service temporarily unavailable"
+	default:
+		return 500, "Internal server error"
+	}
+}
@@ -5,15 +5,16 @@ import (
 	"diaryserver/internal/router/handlers"
 	"diaryserver/internal/router/middleware"
 	"diaryserver/internal/service"
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
 	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(storage *sqlite.Storage, log *slog.Logger, cfg *config.Config) *gin.Engine {
+func SetupRouter(storage storage.Storage, log *slog.Logger, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(func(c *gin.Context) {
@@ -33,11 +34,13 @@ func SetupRouter(storage *sqlite.Storage, log *slog.Logger, cfg *config.Config)
 			slog.Int("errors", len(c.Errors)),
 		)
 	})
+	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.Timeout(cfg.HTTPServer.Timeout))
 	corsConfig := cors.Config{
 		AllowOrigins:     []string{"https://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "Access-Control-Allow-Origin", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers", "Access-Control-Allow-Credentials"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "Access-Control-Allow-Origin", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers", "Access-Control-Allow-Credentials", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           24 * time.Hour,
 	}
@@ -79,17 +82,87 @@ func SetupRouter(storage *sqlite.Storage, log *slog.Logger, cfg *config.Config)
 		calendar.GET("/:date/:workoutId", handlers.NewHandlers(storage, log).LoadTrainingSingle)
 		calendar.GET("/:date", handlers.NewHandlers(storage, log).LoadTrainings)
 		calendar.POST("/:date/new", handlers.NewHandlers(storage, log).CreateTraining)
+		calendar.POST("/:date/from-template/:templateId", handlers.NewHandlers(storage, log).CreateTrainingFromTemplate)
 		calendar.GET("", handlers.NewHandlers(storage, log).LoadCalendar)
 		calendar.DELETE("/:date/:workoutId/exercise", handlers.NewHandlers(storage, log).DeleteExercise)
 		calendar.PUT("/:date/:workoutId/:exerciseId", handlers.NewHandlers(storage, log).ChangeExercise)
 		calendar.PATCH("/workouts/:workoutId", handlers.NewHandlers(storage, log).ChangeWorkoutInfo)
 		calendar.DELETE("/workouts/:workoutId", handlers.NewHandlers(storage, log).DeleteWorkout)
+		calendar.POST("/workouts/:workoutId/template", handlers.NewHandlers(storage, log).SaveWorkoutAsTemplate)
+		calendar.GET("/planned", handlers.NewHandlers(storage, log).LoadPlannedWorkouts)
+		calendar.GET("/planned/:plannedWorkoutId", handlers.NewHandlers(storage, log).LoadPlannedWorkout)
+		calendar.POST("/planned/:plannedWorkoutId/start", handlers.NewHandlers(storage, log).StartPlannedWorkout)
+		calendar.PUT("/recurring/:recurringWorkoutId/:date", handlers.NewHandlers(storage, log).SetRecurrenceException)
+		calendar.DELETE("/recurring/:recurringWorkoutId/:date", handlers.NewHandlers(storage, log).DeleteRecurrenceException)
+		calendar.POST("/recurring/:recurringWorkoutId/:date/start", handlers.NewHandlers(storage, log).StartOccurrence)
+	}
+	trash := r.Group("/trash")
+	trash.Use(middleware.AuthMiddleware(storage, cfg))
+	{
+		trash.GET("", handlers.NewHandlers(storage, log).LoadTrash)
+		trash.POST("/:workoutId/restore", handlers.NewHandlers(storage, log).RestoreWorkout)
+	}
+	exercises := r.Group("/exercises")
+	exercises.Use(middleware.AuthMiddleware(storage, cfg))
+	{
+		exercises.GET("", handlers.NewHandlers(storage, log).LoadCatalog)
+		exercises.GET("/taxonomy", handlers.NewHandlers(storage, log).LoadTaxonomy)
+	}
+	me := r.Group("/me")
+	me.Use(middleware.AuthMiddleware(storage, cfg))
+	{
+		me.GET("/preferences", handlers.NewHandlers(storage, log).GetPreferences)
+		me.PATCH("/preferences", handlers.NewHandlers(storage, log).ChangePreferences)
+		me.GET("/body-weights", handlers.NewHandlers(storage, log).LoadBodyWeights)
+		me.PUT("/body-weights/:date", handlers.NewHandlers(storage, log).SetBodyWeight)
+		me.DELETE("/body-weights/:date", handlers.NewHandlers(storage, log).DeleteBodyWeight)
+		me.GET("/exercises", handlers.NewHandlers(storage, log).LoadCustomExercises)
+		me.POST("/exercises", handlers.NewHandlers(storage, log).CreateCustomExercise)
+		me.PUT("/exercises/:exerciseId", handlers.NewHandlers(storage, log).ChangeCustomExercise)
+		me.DELETE("/exercises/:exerciseId", handlers.NewHandlers(storage, log).DeleteCustomExercise)
+		me.GET("/templates", handlers.NewHandlers(storage, log).LoadTemplates)
+		me.POST("/templates", handlers.NewHandlers(storage, log).CreateTemplate)
+		me.GET("/templates/:templateId", handlers.NewHandlers(storage, log).LoadTemplate)
+		me.PUT("/templates/:templateId", handlers.NewHandlers(storage, log).ChangeTemplate)
+		me.DELETE("/templates/:templateId", handlers.NewHandlers(storage, log).DeleteTemplate)
+		me.GET("/programs", handlers.NewHandlers(storage, log).LoadPrograms)
+		me.POST("/programs", handlers.NewHandlers(storage, log).CreateProgram)
+		me.GET("/programs/:programId", handlers.NewHandlers(storage, log).LoadProgram)
+		me.PUT("/programs/:programId", handlers.NewHandlers(storage, log).ChangeProgram)
+		me.DELETE("/programs/:programId", handlers.NewHandlers(storage, log).DeleteProgram)
+		me.POST("/programs/:programId/enrollments", handlers.NewHandlers(storage, log).EnrollInProgram)
+		me.GET("/enrollments", handlers.NewHandlers(storage, log).LoadEnrollments)
+		me.DELETE("/enrollments/:enrollmentId", handlers.NewHandlers(storage, log).DeleteEnrollment)
+		me.GET("/recurring-workouts", handlers.NewHandlers(storage, log).LoadRecurringWorkouts)
+		me.POST("/recurring-workouts", handlers.NewHandlers(storage, log).CreateRecurringWorkout)
+		me.GET("/recurring-workouts/:recurringWorkoutId", handlers.NewHandlers(storage, log).LoadRecurringWorkout)
+		me.PUT("/recurring-workouts/:recurringWorkoutId", handlers.NewHandlers(storage, log).ChangeRecurringWorkout)
+		me.DELETE("/recurring-workouts/:recurringWorkoutId", handlers.NewHandlers(storage, log).DeleteRecurringWorkout)
+	}
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(storage, cfg), middleware.RequireAdmin(storage, cfg))
+	{
+		admin.GET("/exercises/:exerciseId/translations", handlers.NewHandlers(storage, log).LoadExerciseTranslations)
+		admin.PUT("/exercises/:exerciseId/translations/:locale", handlers.NewHandlers(storage, log).SetExerciseTranslation)
+		admin.DELETE("/exercises/:exerciseId/translations/:locale", handlers.NewHandlers(storage, log).DeleteExerciseTranslation)
+	}
+	search := r.Group("/search")
+	search.Use(middleware.AuthMiddleware(storage, cfg))
+	{
+		search.GET("", handlers.NewHandlers(storage, log).Search)
 	}
 	log.Info("starting HTTPS server",
 		slog.String("port", cfg.TLS.Port),
 		slog.String("cert", cfg.TLS.PathToCert),
 		slog.String("key", cfg.TLS.PathToKey))
-	if err := r.RunTLS(cfg.TLS.Port, cfg.TLS.PathToCert, cfg.TLS.PathToKey); err != nil {
+	srv := &http.Server{
+		Addr:         cfg.TLS.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	}
+	if err := srv.ListenAndServeTLS(cfg.TLS.PathToCert, cfg.TLS.PathToKey); err != nil {
 		log.Error("failed to start HTTPS server", "error", err)
 		return nil
 	}
@@ -0,0 +1,306 @@
+// Package rrule parses and expands RFC 5545 recurrence rules. It covers what
+// a training calendar needs: DAILY, WEEKLY and MONTHLY rules with INTERVAL,
+// BYDAY, BYMONTHDAY, COUNT and UNTIL. Occurrences are whole days; the time
+// of day is kept next to the rule by the caller.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of occurrence dates and UNTIL values given as
+// dates.
+const DateLayout = "2006-01-02"
+
+// Frequency is the FREQ of a rule.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// MaxCount is the largest COUNT Parse accepts. Expanding a rule with COUNT
+// walks its occurrences from the start, so the cap bounds the work of every
+// expansion however far from the start it looks.
+const MaxCount = 1000
+
+// ErrInvalid is wrapped by every error Parse returns.
+var ErrInvalid = errors.New("invalid recurrence rule")
+
+// Rule is a parsed recurrence rule. Weeks start on Monday.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	// Count limits the rule to its first Count occurrences unless zero.
+	Count int
+	// Until is the last day an occurrence may fall on unless zero.
+	Until time.Time
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE,FR". An "RRULE:"
+// prefix is allowed.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalid, part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s given twice", ErrInvalid, name)
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalid, val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalid)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > MaxCount {
+				return nil, fmt.Errorf("%w: COUNT must be an integer from 1 to %d", ErrInvalid, MaxCount)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY value %s", ErrInvalid, day)
+				}
+				if !slices.Contains(rule.ByDay, weekday) {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY values must be between -31 and 31, not 0", ErrInvalid)
+				}
+				if !slices.Contains(rule.ByMonthDay, n) {
+					rule.ByMonthDay = append(rule.ByMonthDay, n)
+				}
+			}
+		case "WKST":
+			if strings.ToUpper(val) != "MO" {
+				return nil, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalid)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalid, name)
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalid)
+	}
+	if rule.Count != 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL must not both be given", ErrInvalid)
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("%w: BYMONTHDAY is not allowed with FREQ=WEEKLY", ErrInvalid)
+	}
+	slices.SortFunc(rule.ByDay, func(a, b time.Weekday) int { return weekdayIndex(a) - weekdayIndex(b) })
+	slices.Sort(rule.ByMonthDay)
+	return rule, nil
+}
+
+// parseUntil accepts a date, 20261231 or 2026-12-31, or a UTC date-time of
+// which only the date counts.
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", DateLayout, "20060102T150405Z"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return day(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: UNTIL must be a date", ErrInvalid)
+}
+
+// String formats the rule in its canonical form.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekday := range r.ByDay {
+			days = append(days, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, n := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(n))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns up to limit occurrences of the rule started on start
+// (DTSTART) that fall between from and to inclusive, in order. As in RFC
+// 5545, start itself is not an occurrence unless the rule matches it.
+func (r *Rule) Between(start, from, to time.Time, limit int) []time.Time {
+	start, from, to = day(start), day(from), day(to)
+	if !r.Until.IsZero() && r.Until.Before(to) {
+		to = r.Until
+	}
+	if from.Before(start) {
+		from = start
+	}
+	var occurrences []time.Time
+	if to.Before(from) || limit < 1 {
+		return occurrences
+	}
+	// Without COUNT nothing before from matters, so whole intervals are
+	// skipped.
+	period := 0
+	if r.Count == 0 {
+		period = r.periodsBetween(start, from) / r.Interval * r.Interval
+	}
+	counted := 0
+	for ; ; period += r.Interval {
+		candidates, first := r.candidates(start, period)
+		if first.After(to) {
+			return occurrences
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+			counted++
+			if r.Count > 0 && counted > r.Count {
+				return occurrences
+			}
+			if candidate.Before(from) {
+				continue
+			}
+			if candidate.After(to) {
+				return occurrences
+			}
+			occurrences = append(occurrences, candidate)
+			if len(occurrences) == limit {
+				return occurrences
+			}
+		}
+	}
+}
+
+// Occurs tells whether date is an occurrence of the rule started on start.
+func (r *Rule) Occurs(start, date time.Time) bool {
+	return len(r.Between(start, date, date, 1)) == 1
+}
+
+// periodsBetween counts the days, weeks or months from the period of start
+// to the period of date.
+func (r *Rule) periodsBetween(start, date time.Time) int {
+	switch r.Freq {
+	case Weekly:
+		return int(weekStart(date).Sub(weekStart(start)).Hours()/24) / 7
+	case Monthly:
+		return (date.Year()-start.Year())*12 + int(date.Month()-start.Month())
+	default:
+		return int(date.Sub(start).Hours() / 24)
+	}
+}
+
+// candidates returns the days of the period-th period after start's that
+// match the rule, in order, and the first day of that period.
+func (r *Rule) candidates(start time.Time, period int) ([]time.Time, time.Time) {
+	var days []time.Time
+	switch r.Freq {
+	case Weekly:
+		first := weekStart(start).AddDate(0, 0, 7*period)
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		for _, weekday := range byDay {
+			days = append(days, first.AddDate(0, 0, weekdayIndex(weekday)))
+		}
+		return days, first
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+		length := first.AddDate(0, 1, -1).Day()
+		byMonthDay := r.ByMonthDay
+		if len(byMonthDay) == 0 && len(r.ByDay) == 0 {
+			byMonthDay = []int{start.Day()}
+		}
+		for n := 1; n <= length; n++ {
+			date := first.AddDate(0, 0, n-1)
+			if len(byMonthDay) > 0 && !slices.Contains(byMonthDay, n) && !slices.Contains(byMonthDay, n-length-1) {
+				continue
+			}
+			if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, date.Weekday()) {
+				continue
+			}
+			days = append(days, date)
+		}
+		return days, first
+	default:
+		date := start.AddDate(0, 0, period)
+		if (len(r.ByDay) == 0 || slices.Contains(r.ByDay, date.Weekday())) &&
+			(len(r.ByMonthDay) == 0 || slices.Contains(r.ByMonthDay, date.Day()) ||
+				slices.Contains(r.ByMonthDay, date.Day()-date.AddDate(0, 1, -date.Day()).Day()-1)) {
+			days = append(days, date)
+		}
+		return days, date
+	}
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekdayIndex numbers weekdays from Monday, 0, to Sunday, 6.
+func weekdayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -weekdayIndex(t.Weekday()))
+}
@@ -0,0 +1,258 @@
+package rrule
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+	d, err := time.Parse(DateLayout, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"daily", "FREQ=DAILY", "FREQ=DAILY"},
+		{"prefix and case", "RRULE:freq=weekly;byday=fr,mo,fr", "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{"interval one is dropped", "FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"weekly interval", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH"},
+		{"month days sorted", "FREQ=MONTHLY;BYMONTHDAY=15,-1,1", "FREQ=MONTHLY;BYMONTHDAY=-1,1,15"},
+		{"count", "FREQ=DAILY;COUNT=10", "FREQ=DAILY;COUNT=10"},
+		{"until date", "FREQ=DAILY;UNTIL=2026-12-31", "FREQ=DAILY;UNTIL=20261231"},
+		{"until date-time", "FREQ=DAILY;UNTIL=20261231T235959Z", "FREQ=DAILY;UNTIL=20261231"},
+		{"week starts on Monday", "FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.value, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"no frequency", "BYDAY=MO"},
+		{"yearly", "FREQ=YEARLY"},
+		{"malformed part", "FREQ=DAILY;COUNT"},
+		{"repeated part", "FREQ=DAILY;FREQ=WEEKLY"},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0"},
+		{"negative count", "FREQ=DAILY;COUNT=-1"},
+		{"count above the cap", "FREQ=DAILY;COUNT=1001"},
+		{"count and until", "FREQ=DAILY;COUNT=3;UNTIL=20261231"},
+		{"bad until", "FREQ=DAILY;UNTIL=tomorrow"},
+		{"bad weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"ordinal weekday", "FREQ=MONTHLY;BYDAY=1MO"},
+		{"month day zero", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"month day out of range", "FREQ=MONTHLY;BYMONTHDAY=-32"},
+		{"month day with weekly", "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{"week starts on Sunday", "FREQ=WEEKLY;WKST=SU"},
+		{"unsupported part", "FREQ=DAILY;BYHOUR=9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.value); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalid", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	// 2026-01-05 is a Monday.
+	tests := []struct {
+		name     string
+		rule     string
+		start    string
+		from, to string
+		limit    int
+		want     []string
+	}{
+		{
+			name: "daily", rule: "FREQ=DAILY;INTERVAL=3", start: "2026-01-01",
+			from: "2026-01-05", to: "2026-01-15", limit: 10,
+			want: []string{"2026-01-07", "2026-01-10", "2026-01-13"},
+		},
+		{
+			name: "start before from is not returned", rule: "FREQ=DAILY", start: "2026-01-10",
+			from: "2026-01-01", to: "2026-01-11", limit: 10,
+			want: []string{"2026-01-10", "2026-01-11"},
+		},
+		{
+			name: "start off the rule is not an occurrence", rule: "FREQ=WEEKLY;BYDAY=MO", start: "2026-01-07",
+			from: "2026-01-01", to: "2026-01-20", limit: 10,
+			want: []string{"2026-01-12", "2026-01-19"},
+		},
+		{
+			name: "weekly without days repeats the start day", rule: "FREQ=WEEKLY", start: "2026-01-07",
+			from: "2026-01-01", to: "2026-01-21", limit: 10,
+			want: []string{"2026-01-07", "2026-01-14", "2026-01-21"},
+		},
+		{
+			name: "weekly interval with days", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", start: "2026-01-07",
+			from: "2026-01-01", to: "2026-02-05", limit: 10,
+			want: []string{"2026-01-08", "2026-01-20", "2026-01-22", "2026-02-03", "2026-02-05"},
+		},
+		{
+			name: "weekly interval from a later week", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", start: "2026-01-07",
+			from: "2026-01-21", to: "2026-02-04", limit: 10,
+			want: []string{"2026-01-22", "2026-02-03"},
+		},
+		{
+			name: "weekly interval skips off weeks", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", start: "2026-01-07",
+			from: "2026-01-12", to: "2026-01-18", limit: 10,
+			want: nil,
+		},
+		{
+			name: "monthly on the start day", rule: "FREQ=MONTHLY", start: "2026-01-31",
+			from: "2026-01-01", to: "2026-05-31", limit: 10,
+			want: []string{"2026-01-31", "2026-03-31", "2026-05-31"},
+		},
+		{
+			name: "negative month day", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: "2026-01-15",
+			from: "2026-01-01", to: "2026-04-30", limit: 10,
+			want: []string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			name: "negative month day in a leap year", rule: "FREQ=MONTHLY;BYMONTHDAY=-2", start: "2028-02-01",
+			from: "2028-02-01", to: "2028-03-31", limit: 10,
+			want: []string{"2028-02-28", "2028-03-30"},
+		},
+		{
+			name: "month days counted from both ends", rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1", start: "2026-02-01",
+			from: "2026-02-01", to: "2026-03-31", limit: 10,
+			want: []string{"2026-02-01", "2026-02-28", "2026-03-01", "2026-03-31"},
+		},
+		{
+			name: "monthly weekdays", rule: "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", start: "2026-01-01",
+			from: "2026-01-01", to: "2026-12-31", limit: 10,
+			want: []string{"2026-02-13", "2026-03-13", "2026-11-13"},
+		},
+		{
+			name: "daily negative month day", rule: "FREQ=DAILY;BYMONTHDAY=-1", start: "2026-01-01",
+			from: "2026-01-01", to: "2026-03-31", limit: 10,
+			want: []string{"2026-01-31", "2026-02-28", "2026-03-31"},
+		},
+		{
+			name: "count", rule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", start: "2026-01-05",
+			from: "2026-01-01", to: "2026-12-31", limit: 10,
+			want: []string{"2026-01-05", "2026-01-07", "2026-01-12", "2026-01-14"},
+		},
+		{
+			name: "count counts from the start, not from", rule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", start: "2026-01-05",
+			from: "2026-01-12", to: "2026-12-31", limit: 10,
+			want: []string{"2026-01-12", "2026-01-14"},
+		},
+		{
+			name: "count ignores days before the start", rule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=2", start: "2026-01-07",
+			from: "2026-01-01", to: "2026-12-31", limit: 10,
+			want: []string{"2026-01-07", "2026-01-12"},
+		},
+		{
+			name: "count exhausted before a far-away from", rule: "FREQ=DAILY;COUNT=5", start: "2026-01-01",
+			from: "2036-01-01", to: "2036-12-31", limit: 10,
+			want: nil,
+		},
+		{
+			name: "largest count with a far-away from", rule: "FREQ=DAILY;COUNT=1000", start: "2026-01-01",
+			from: "2028-09-26", to: "2099-12-31", limit: 10,
+			want: []string{"2028-09-26"},
+		},
+		{
+			name: "until clamps to", rule: "FREQ=DAILY;UNTIL=20260105", start: "2026-01-01",
+			from: "2026-01-03", to: "2026-01-31", limit: 10,
+			want: []string{"2026-01-03", "2026-01-04", "2026-01-05"},
+		},
+		{
+			name: "until keeps an earlier to", rule: "FREQ=DAILY;UNTIL=20260131", start: "2026-01-01",
+			from: "2026-01-03", to: "2026-01-04", limit: 10,
+			want: []string{"2026-01-03", "2026-01-04"},
+		},
+		{
+			name: "until before from", rule: "FREQ=DAILY;UNTIL=20260105", start: "2026-01-01",
+			from: "2026-02-01", to: "2026-02-28", limit: 10,
+			want: nil,
+		},
+		{
+			name: "until date-time counts the whole day", rule: "FREQ=DAILY;UNTIL=20260105T080000Z", start: "2026-01-04",
+			from: "2026-01-01", to: "2026-01-31", limit: 10,
+			want: []string{"2026-01-04", "2026-01-05"},
+		},
+		{
+			name: "limit", rule: "FREQ=DAILY", start: "2026-01-01",
+			from: "2026-01-01", to: "2026-12-31", limit: 2,
+			want: []string{"2026-01-01", "2026-01-02"},
+		},
+		{
+			name: "to before from", rule: "FREQ=DAILY", start: "2026-01-01",
+			from: "2026-01-10", to: "2026-01-09", limit: 10,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, occurrence := range rule.Between(date(t, tt.start), date(t, tt.from), date(t, tt.to), tt.limit) {
+				got = append(got, occurrence.Format(DateLayout))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Between = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurs(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		date  string
+		want  bool
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,WE", "2026-01-05", "2026-01-07", true},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", "2026-01-05", "2026-01-08", false},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", "2026-01-05", "2025-12-31", false},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-01-05", "2026-01-19", true},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-01-05", "2026-01-12", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-01", "2026-02-28", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-01", "2026-02-27", false},
+		{"FREQ=DAILY;COUNT=3", "2026-01-01", "2026-01-03", true},
+		{"FREQ=DAILY;COUNT=3", "2026-01-01", "2026-01-04", false},
+		{"FREQ=DAILY;UNTIL=20260103", "2026-01-01", "2026-01-03", true},
+		{"FREQ=DAILY;UNTIL=20260103", "2026-01-01", "2026-01-04", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.date, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.Occurs(date(t, tt.start), date(t, tt.date)); got != tt.want {
+				t.Errorf("Occurs(%s, %s) = %v, want %v", tt.start, tt.date, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"context"
+	"diaryserver/internal/storage"
+)
+
+// demoUsers are the ten accounts that migration 3 used to create.
+var demoUsers = []storage.User{
+	{Username: "user1", Email: "user1@gmail.com", PasswordHash: "$2a$10$.zHxf/qDCaDAO4kDm/BM3.GY1Ds/ZsUWfdRAi.bIf62ppHHD/ZL2a"},
+	{Username: "user2", Email: "user2@gmail.com", PasswordHash: "$2a$10$H/PDzYeYep8AIylp09pi.e/nf2kdw6Gj4KtZvFZANpzCojjIJISgu"},
+	{Username: "user3", Email: "user3@gmail.com", PasswordHash: "$2a$10$YrLcZKq/CJvVSl7HjBrsDulu2ZMGVE1FLX8NW.wfBpbss3xOdhkvm"},
+	{Username: "user4", Email: "user4@gmail.com", PasswordHash: "$2a$10$Ey3sOO5TT.8GFy59WhL8N.C6eK/HPK7xk66ZNMWjtZedR28x82/bK"},
+	{Username: "user5", Email: "user5@gmail.com", PasswordHash: "$2a$10$.L4jJ4p31D2rww7BHjgUwONgYXRjm.Zsn.VSMXhPjQKo9zfQCcwsq"},
+	{Username: "user6", Email: "user6@gmail.com", PasswordHash: "$2a$10$K2Y0SXOQcsYmyDtUqF7zgujRJmxLtK4eJ2XDmA1w2UBjcjLzTroM6"},
+	{Username: "user7", Email: "user7@gmail.com", PasswordHash: "$2a$10$GN7Zsoa7SQAJzx12tPmPgurpxS6gTcc969pIyzjYG1uKC2obcipgS"},
+	{Username: "user8", Email: "user8@gmail.com", PasswordHash: "$2a$10$00d9Oit8Qt3/bEFMCpkOzuqA5fIW0KRmZhTe17RqUK8S7bxrpZvQ6"},
+	{Username: "user9", Email: "user9@gmail.com", PasswordHash: "$2a$10$LqCGTjmCjETDsoH6sfADGOqdYorVOLGVUxdusp45BmMfegE0Y2SLu"},
+	{Username: "user10", Email: "user10@gmail.com", PasswordHash: "$2a$10$ANiZe5qU0sW.hfWg74t4BuMrCf3dM8K4.S12tKe4H8Bky/zoNlaSq"},
+}
+
+// demoWorkouts are the two sessions of user1 that migrations 4-6 used to create.
+var demoWorkouts = []workout{
+	{
+		date: "2025-01-01", startTime: "08:00:04", endTime: "09:00:24", notes: "Тренировка на силу",
+		exercises: []exercise{
+			{name: "Жим лёжа", sets: sets(20, 75.5, 13, 52.5, 5, 45.0, 11, 32.5, 19, 67.5)},
+			{name: "Отжимания", sets: sets(15, 40.0, 8, 75.0, 10, 90.0, 13, 60.0, 11, 47.5)},
+			{name: "Приседания", sets: sets(18, 82.5, 12, 72.5, 16, 65.0, 14, 55.0, 8, 40.0)},
+		},
+	},
+	{
+		date: "2025-01-01", startTime: "10:01:02", endTime: "11:00:03", notes: "Кардио тренировка",
+		exercises: []exercise{
+			{name: "Подтягивания", sets: sets(14, 45.0, 18, 60.0, 7, 35.0, 16, 67.5, 12, 82.5)},
+			{name: "Приседания", sets: sets(17, 52.5, 14, 85.0, 9, 57.5, 12, 50.0, 6, 65.0)},
+			{name: "Жим лёжа", sets: sets(4, 95.0, 20, 45.0, 10, 90.0, 19, 40.0, 17, 52.5)},
+			{name: "Отжимания", sets: sets(15, 75.0, 9, 67.5, 18, 55.0, 11, 40.0, 16, 62.5)},
+		},
+	},
+}
+
+// Demo restores the sample users and workouts that used to ship as migrations.
+func Demo(ctx context.Context, store storage.Storage) error {
+	exerciseIDs, err := catalog(ctx, store)
+	if err != nil {
+		return err
+	}
+	var ownerID int64
+	for i, user := range demoUsers {
+		userID, err := ensureUser(ctx, store, user)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			ownerID = userID
+		}
+	}
+	for _, w := range demoWorkouts {
+		if err := ensureWorkout(ctx, store, exerciseIDs, ownerID, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sets builds sets from repetitions/weight pairs.
+func sets(pairs ...float64) []storage.Set {
+	result := make([]storage.Set, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, storage.Set{Repetitions: int(pairs[i]), Weight: pairs[i+1]})
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package seed
+
+import (
+	"context"
+	"diaryserver/internal/service"
+	"diaryserver/internal/storage"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	loadTestUsers    = 10
+	loadTestPassword = "loadtest-password"
+	loadTestFrom     = "2024-01-01"
+	loadTestDays     = 366
+	loadTestEvery    = 2 // days between workouts
+)
+
+// LoadTest creates loadTestUsers users with a workout every loadTestEvery days
+// over a year, made of sets of repetitions with a weight. The data is
+// generated from a fixed seed, so runs against the same global catalog
+// produce the same workouts; a rerun only adds the workouts that are missing
+// and leaves the ones already there as they are, even if the catalog has
+// changed since.
+func LoadTest(ctx context.Context, store storage.Storage) error {
+	exerciseIDs, err := catalog(ctx, store)
+	if err != nil {
+		return err
+	}
+	exercises, err := store.GetAllowedExercises(ctx)
+	if err != nil {
+		return err
+	}
+	// Only weighted exercises take the generated sets; bodyweight ones would
+	// read them as added load.
+	names := make([]string, 0, len(exercises))
+	for _, e := range exercises {
+		if e.MeasurementType == storage.MeasurementRepsWeight && !e.Bodyweight {
+			names = append(names, e.Name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("exercise catalog has no weighted exercises")
+	}
+	// The catalog comes in no particular order; sort for a stable sequence.
+	sort.Strings(names)
+
+	hash, err := service.HashPassword(loadTestPassword)
+	if err != nil {
+		return err
+	}
+	from, err := time.Parse("2006-01-02", loadTestFrom)
+	if err != nil {
+		return err
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for u := 1; u <= loadTestUsers; u++ {
+		userID, err := ensureUser(ctx, store, storage.User{
+			Username:     fmt.Sprintf("loadtest%02d", u),
+			Email:        fmt.Sprintf("loadtest%02d@example.com", u),
+			PasswordHash: hash,
+		})
+		if err != nil {
+			return err
+		}
+		for day := 0; day < loadTestDays; day += loadTestEvery {
+			start := 6 + rng.Intn(14)
+			w := workout{
+				date:      from.AddDate(0, 0, day).Format("2006-01-02"),
+				startTime: fmt.Sprintf("%02d:00:00", start),
+				endTime:   fmt.Sprintf("%02d:15:00", start+1),
+			}
+			for e := 0; e < 3; e++ {
+				ex := exercise{name: names[rng.Intn(len(names))]}
+				for s := 0; s < 4; s++ {
+					ex.sets = append(ex.sets, storage.Set{
+						Repetitions: 5 + rng.Intn(8),
+						Weight:      float64(20+rng.Intn(30)) * 2.5,
+					})
+				}
+				w.exercises = append(w.exercises, ex)
+			}
+			if err := ensureWorkout(ctx, store, exerciseIDs, userID, w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package seed fills a database with named sample datasets. Every dataset can
+// be applied repeatedly: rows that are already present are left alone.
+package seed
+
+import (
+	"context"
+	"diaryserver/internal/storage"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type Dataset func(ctx context.Context, store storage.Storage) error
+
+var datasets = map[string]Dataset{
+	"demo":      Demo,
+	"load-test": LoadTest,
+}
+
+// Names lists the available datasets.
+func Names() []string {
+	names := make([]string, 0, len(datasets))
+	for name := range datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Apply(ctx context.Context, store storage.Storage, name string) error {
+	dataset, ok := datasets[name]
+	if !ok {
+		return fmt.Errorf("unknown dataset %q", name)
+	}
+	if err := dataset(ctx, store); err != nil {
+		return fmt.Errorf("dataset %s: %w", name, err)
+	}
+	return nil
+}
+
+type exercise struct {
+	name string
+	sets []storage.Set
+}
+
+type workout struct {
+	date      string
+	startTime string
+	endTime   string
+	notes     string
+	exercises []exercise
+}
+
+// ensureUser returns the ID of user, creating it first if needed.
+func ensureUser(ctx context.Context, store storage.Storage, user storage.User) (int64, error) {
+	existing, err := store.GetUser(ctx, user.Username)
+	if err == nil {
+		return existing.UserID, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return 0, err
+	}
+	if err := store.AddUser(ctx, user); err != nil {
+		return 0, err
+	}
+	existing, err = store.GetUser(ctx, user.Username)
+	if err != nil {
+		return 0, err
+	}
+	return existing.UserID, nil
+}
+
+// ensureWorkout adds w for userID unless the user already has a workout on
+// the same date starting at the same time. The workout, its exercises and its
+// sets are written in one transaction, so an interrupted run never leaves a
+// partial workout that a later run would take for a complete one.
+func ensureWorkout(ctx context.Context, store storage.Storage, exerciseIDs map[string]int64, userID int64, w workout) error {
+	return store.WithTx(ctx, func(tx storage.Repos) error {
+		existing, err := tx.GetWorkoutsFromDate(ctx, userID, w.date)
+		if err != nil {
+			return err
+		}
+		for _, e := range existing {
+			if e.StartTime == w.startTime {
+				return nil
+			}
+		}
+
+		workoutID, err := tx.AddWorkout(ctx, storage.Workout{
+			UserID:    userID,
+			Date:      w.date,
+			StartTime: w.startTime,
+			EndTime:   w.endTime,
+			Notes:     w.notes,
+		})
+		if err != nil {
+			return err
+		}
+		for _, ex := range w.exercises {
+			exerciseID, ok := exerciseIDs[ex.name]
+			if !ok {
+				return fmt.Errorf("exercise %q is not in the catalog", ex.name)
+			}
+			workoutExerciseID, err := tx.AddWorkoutExercise(ctx, storage.WorkoutExercise{
+				WorkoutID:  workoutID,
+				ExerciseID: exerciseID,
+			})
+			if err != nil {
+				return err
+			}
+			sets := make([]storage.Set, 0, len(ex.sets))
+			for _, set := range ex.sets {
+				set.WorkoutExerciseID = workoutExerciseID
+				sets = append(sets, set)
+			}
+			if err := tx.AddSets(ctx, sets); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func catalog(ctx context.Context, store storage.Storage) (map[string]int64, error) {
+	exercises, err := store.GetAllowedExercises(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(exercises))
+	for _, e := range exercises {
+		ids[e.Name] = e.AllowedExerciseId
+	}
+	return ids, nil
+}
@@ -1,7 +1,8 @@
 package service
 
 import (
-	"diaryserver/internal/storage/sqlite"
+	"context"
+	"diaryserver/internal/storage"
 	"errors"
 	"fmt"
 	"time"
@@ -10,14 +11,14 @@ import (
 )
 
 type AuthService struct {
-	storage       *sqlite.Storage
+	storage       storage.Storage
 	accessSecret  string
 	refreshSecret string
 	accessTTL     time.Duration
 	refreshTTL    time.Duration
 }
 
-func NewAuthService(storage *sqlite.Storage, accessSecret, refreshSecret string, accessTTL, refreshTTL time.Duration) *AuthService {
+func NewAuthService(storage storage.Storage, accessSecret, refreshSecret string, accessTTL, refreshTTL time.Duration) *AuthService {
 	return &AuthService{
 		storage:       storage,
 		accessSecret:  accessSecret,
@@ -27,8 +28,8 @@ func NewAuthService(storage *sqlite.Storage, accessSecret, refreshSecret string,
 	}
 }
 
-func (s *AuthService) ValidateUser(username, password string) (*sqlite.UserInfo, error) {
-	user, err := s.storage.GetUser(username)
+func (s *AuthService) ValidateUser(ctx context.Context, username, password string) (*storage.UserInfo, error) {
+	user, err := s.storage.GetUser(ctx, username)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +47,7 @@ func (AuthService *AuthService) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := AuthService.ValidateUser(request.User.Username, request.User.Password)
+	user, err := AuthService.ValidateUser(c.Request.Context(), request.User.Username, request.User.Password)
 	if err != nil {
 		logger.Error("failed to validate user", "error", err)
 		c.JSON(401, gin.H{"error": "invalid credentials"})
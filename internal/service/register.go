@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"log/slog"
 
-	"diaryserver/internal/storage/sqlite"
+	"diaryserver/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -47,14 +47,13 @@ func (AuthService *AuthService) Register(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "Internal server error"})
 		return
 	}
-	err = c.MustGet("db").(*sqlite.Storage).AddUser(sqlite.User{
+	err = c.MustGet("db").(storage.Storage).AddUser(c.Request.Context(), storage.User{
 		Username:     request.User.Username,
 		Email:        request.User.Email,
 		PasswordHash: hashedPassword,
 	})
 	if err != nil {
-		logger.Error("failed to create user", "error", err)
-		c.JSON(500, gin.H{"error": "Internal server error"})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(200, gin.H{"message": "Registration successful"})
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"diaryserver/internal/config"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/postgres"
+	"diaryserver/internal/storage/sqlite"
+	"fmt"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open connects to the storage selected in cfg.
+func Open(cfg *config.Config) (storage.Storage, error) {
+	keys, err := keyring(cfg.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Storage.Driver {
+	case config.StorageDriverPostgres:
+		s, err := postgres.New(cfg.Storage.DSN, cfg.Storage.QueryTimeout, keys)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		s, err := sqlite.New(cfg.StoragePath, cfg.Storage.QueryTimeout, keys)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// keyring returns the keyring for the configured master key, or nil when
+// encryption is off.
+func keyring(cfg config.Encryption) (*encryption.Keyring, error) {
+	if cfg.MasterKey == "" {
+		return nil, nil
+	}
+	master, err := encryption.ParseKey(cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("storage.backend.Open: %w", err)
+	}
+	return encryption.NewKeyring(master), nil
+}
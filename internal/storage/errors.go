@@ -0,0 +1,31 @@
+package storage
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("already exists")
+	ErrForeignKey = errors.New("referenced row does not exist")
+	// ErrVersionMismatch is returned by conditional writes when the workout
+	// has changed since the caller read it.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrSearchUnavailable is returned by searches on a database without a
+	// full-text index, such as SQLite built without FTS5.
+	ErrSearchUnavailable = errors.New("full-text search is not available")
+)
+
+// ConstraintError is returned when the database rejects a write because of a
+// constraint. Kind is ErrConflict or ErrForeignKey, so callers can match it
+// with errors.Is; Err is the original driver error.
+type ConstraintError struct {
+	Kind error
+	Err  error
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
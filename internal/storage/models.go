@@ -0,0 +1,590 @@
+package storage
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"diaryserver/internal/rrule"
+	"math"
+	"slices"
+	"time"
+)
+
+type User struct {
+	Username     string
+	Email        string
+	PasswordHash string
+}
+
+type UserInfo struct {
+	UserID       int64
+	Username     string
+	Email        string
+	PasswordHash string
+	CreatedAt    string
+	WeightUnit   WeightUnit
+}
+
+// WeightUnit is the unit a user enters and reads weights in. Weights are
+// always stored in kilograms.
+type WeightUnit string
+
+const (
+	WeightUnitKg WeightUnit = "kg"
+	WeightUnitLb WeightUnit = "lb"
+)
+
+// kgPerLb is the exact international avoirdupois pound.
+const kgPerLb = 0.45359237
+
+func (u WeightUnit) Valid() bool {
+	return u == WeightUnitKg || u == WeightUnitLb
+}
+
+// Value stores a user without a unit as using kilograms.
+func (u WeightUnit) Value() (driver.Value, error) {
+	if u == "" {
+		return string(WeightUnitKg), nil
+	}
+	return string(u), nil
+}
+
+// ToKg converts weight from u to kilograms.
+func (u WeightUnit) ToKg(weight float64) float64 {
+	if u == WeightUnitLb {
+		return weight * kgPerLb
+	}
+	return weight
+}
+
+// FromKg converts weight from kilograms to u, rounded to hundredths so that
+// values entered in u read back as entered.
+func (u WeightUnit) FromKg(weight float64) float64 {
+	if u == WeightUnitLb {
+		weight /= kgPerLb
+	}
+	return math.Round(weight*100) / 100
+}
+
+// BodyWeight is a user's body weight in kilograms on a day. There is at most
+// one entry per user and day.
+type BodyWeight struct {
+	UserID int64   `json:"userId"`
+	Date   string  `json:"date"`
+	Weight float64 `json:"weight"`
+}
+
+type Workout struct {
+	UserID    int64  `json:"userId"`
+	Date      string `json:"date"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Notes     string `json:"notes"`
+	Photo     string `json:"photo"`
+}
+type WorkoutInfo struct {
+	WorkoutID int64  `json:"workoutId"`
+	UserID    int64  `json:"userId"`
+	Date      string `json:"date"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Notes     string `json:"notes"`
+	Photo     string `json:"photo"`
+	// Version is bumped on every change to the workout, its exercises or
+	// its sets.
+	Version int64 `json:"version"`
+}
+
+type WorkoutExercise struct {
+	WorkoutID  int64
+	ExerciseID int64
+}
+
+type WorkoutExerciseInfo struct {
+	WorkoutExerciseID int64
+	WorkoutID         int64
+	ExerciseID        int64
+}
+
+type Set struct {
+	WorkoutExerciseID int64
+	Repetitions       int
+	Weight            float64
+	SetDetails
+}
+type SetInfo struct {
+	SetID             int64   `json:"setId"`
+	WorkoutExerciseID int64   `json:"workoutExerciseId"`
+	Repetitions       int     `json:"repetitions"`
+	Weight            float64 `json:"weight"`
+	SetDetails
+	// EffectiveLoad is, for bodyweight exercises, the body weight plus Weight.
+	// It is derived from the latest body weight logged on or before the day
+	// of the workout and is nil without one; it is never stored.
+	EffectiveLoad *float64 `json:"effectiveLoad"`
+}
+
+// SetDetails records what kind of set it was, how hard it felt and, for
+// cardio and timed exercises, how long and far it went. Fields left nil were
+// not recorded.
+type SetDetails struct {
+	Type SetType `json:"type"`
+	// RPE is the rate of perceived exertion, 1 to 10 in steps of 0.5.
+	RPE *float64 `json:"rpe"`
+	// RIR is the number of repetitions left in reserve.
+	RIR         *int `json:"rir"`
+	RestSeconds *int `json:"restSeconds"`
+	// Tempo is the seconds spent in each phase of a repetition, such as
+	// "3-1-X-0" for eccentric, pause, concentric (X is explosive) and pause.
+	Tempo *string `json:"tempo"`
+	// DurationSeconds and DistanceMeters are recorded for timed and distance
+	// exercises instead of repetitions.
+	DurationSeconds *int     `json:"durationSeconds"`
+	DistanceMeters  *float64 `json:"distanceMeters"`
+	// PaceSecondsPerKm and SpeedKmh are derived from the distance and duration
+	// by Derive; they are never stored.
+	PaceSecondsPerKm *float64 `json:"paceSecondsPerKm"`
+	SpeedKmh         *float64 `json:"speedKmh"`
+}
+
+// Derive fills in the pace and speed of a set with a distance and duration.
+func (d *SetDetails) Derive() {
+	d.PaceSecondsPerKm, d.SpeedKmh = nil, nil
+	if d.DurationSeconds == nil || d.DistanceMeters == nil || *d.DurationSeconds <= 0 || *d.DistanceMeters <= 0 {
+		return
+	}
+	seconds, meters := float64(*d.DurationSeconds), *d.DistanceMeters
+	pace := math.Round(seconds/(meters/1000)*10) / 10
+	speed := math.Round(meters/1000/(seconds/3600)*100) / 100
+	d.PaceSecondsPerKm, d.SpeedKmh = &pace, &speed
+}
+
+type SetType string
+
+const (
+	SetTypeWarmup  SetType = "warmup"
+	SetTypeWorking SetType = "working"
+	SetTypeDrop    SetType = "drop"
+	SetTypeFailure SetType = "failure"
+	SetTypeAMRAP   SetType = "amrap"
+)
+
+func (t SetType) Valid() bool {
+	switch t {
+	case SetTypeWarmup, SetTypeWorking, SetTypeDrop, SetTypeFailure, SetTypeAMRAP:
+		return true
+	}
+	return false
+}
+
+// Value stores a set without a type as a working set.
+func (t SetType) Value() (driver.Value, error) {
+	if t == "" {
+		return string(SetTypeWorking), nil
+	}
+	return string(t), nil
+}
+
+// WorkoutTemplate is a named, ordered list of exercises with the sets to aim
+// for, to start workouts from. Weights are in kilograms.
+type WorkoutTemplate struct {
+	TemplateID int64              `json:"templateId"`
+	UserID     int64              `json:"userId"`
+	Name       string             `json:"name"`
+	Notes      string             `json:"notes"`
+	Exercises  []TemplateExercise `json:"exercises"`
+}
+
+// TemplateExercise is an exercise of a template with its target sets.
+// ExerciseName is filled in when the template is read.
+type TemplateExercise struct {
+	ExerciseID   int64         `json:"exerciseId"`
+	ExerciseName string        `json:"exerciseName"`
+	Sets         []TemplateSet `json:"sets"`
+}
+
+type TemplateSet struct {
+	Repetitions int     `json:"repetitions"`
+	Weight      float64 `json:"weight"`
+	// PercentOfTrainingMax gives the load as a percentage of the training
+	// max of a program enrollment instead of Weight.
+	PercentOfTrainingMax *float64 `json:"percentOfTrainingMax"`
+	SetDetails
+}
+
+// ResolveLoads sets the weight of percentage sets from trainingMaxes. Sets
+// of exercises without a training max keep their weight.
+func (t *WorkoutTemplate) ResolveLoads(trainingMaxes []TrainingMax) {
+	maxes := make(map[int64]float64, len(trainingMaxes))
+	for _, tm := range trainingMaxes {
+		maxes[tm.ExerciseID] = tm.Weight
+	}
+	for i := range t.Exercises {
+		tm, ok := maxes[t.Exercises[i].ExerciseID]
+		if !ok {
+			continue
+		}
+		for j := range t.Exercises[i].Sets {
+			if set := &t.Exercises[i].Sets[j]; set.PercentOfTrainingMax != nil {
+				set.Weight = tm * *set.PercentOfTrainingMax / 100
+			}
+		}
+	}
+}
+
+// Program is a training program of several weeks, each listing the days
+// on which a template is trained.
+type Program struct {
+	ProgramID   int64         `json:"programId"`
+	UserID      int64         `json:"userId"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Weeks       []ProgramWeek `json:"weeks"`
+}
+
+type ProgramWeek struct {
+	Days []ProgramDay `json:"days"`
+}
+
+// ProgramDay is a session of a program on Day, 1 to 7, of its week.
+// TemplateName is filled in when the program is read.
+type ProgramDay struct {
+	Day          int    `json:"day"`
+	TemplateID   int64  `json:"templateId"`
+	TemplateName string `json:"templateName"`
+}
+
+// Enrollment is a user following a program from StartDate, the first day
+// of its first week.
+type Enrollment struct {
+	EnrollmentID  int64         `json:"enrollmentId"`
+	UserID        int64         `json:"userId"`
+	ProgramID     int64         `json:"programId"`
+	ProgramName   string        `json:"programName"`
+	StartDate     string        `json:"startDate"`
+	TrainingMaxes []TrainingMax `json:"trainingMaxes"`
+}
+
+// TrainingMax is the weight in kilograms that percentage loads of an
+// exercise are taken of.
+type TrainingMax struct {
+	ExerciseID int64   `json:"exerciseId"`
+	Weight     float64 `json:"weight"`
+}
+
+// PlannedWorkout is a session of a program enrollment planned on Date. It
+// is completed by the workout logged on that date, WorkoutID; a workout in
+// the trash does not count.
+type PlannedWorkout struct {
+	PlannedWorkoutID int64  `json:"plannedWorkoutId"`
+	EnrollmentID     int64  `json:"enrollmentId"`
+	ProgramName      string `json:"programName"`
+	Week             int    `json:"week"`
+	Day              int    `json:"day"`
+	Date             string `json:"date"`
+	TemplateID       int64  `json:"templateId"`
+	TemplateName     string `json:"templateName"`
+	WorkoutID        *int64 `json:"workoutId"`
+	Completed        bool   `json:"completed"`
+}
+
+// RecurringWorkout is a series of planned workouts from StartDate at Time
+// of day, repeating by RRule, an RFC 5545 recurrence rule such as
+// FREQ=WEEKLY;BYDAY=MO,WE,FR. Its occurrences are started from TemplateID
+// when set.
+type RecurringWorkout struct {
+	RecurringWorkoutID int64                 `json:"recurringWorkoutId"`
+	UserID             int64                 `json:"userId"`
+	Title              string                `json:"title"`
+	Notes              string                `json:"notes"`
+	StartDate          string                `json:"startDate"`
+	Time               string                `json:"time"`
+	RRule              string                `json:"rrule"`
+	TemplateID         *int64                `json:"templateId"`
+	Exceptions         []RecurrenceException `json:"exceptions"`
+}
+
+// RecurrenceAction is what an exception does to its occurrence.
+type RecurrenceAction string
+
+const (
+	RecurrenceSkip RecurrenceAction = "skip"
+	RecurrenceMove RecurrenceAction = "move"
+)
+
+// Value stores an exception without an action as NULL.
+func (a RecurrenceAction) Value() (driver.Value, error) {
+	if a == "" {
+		return nil, nil
+	}
+	return string(a), nil
+}
+
+// RecurrenceException changes the occurrence the rule puts on Date: it is
+// skipped, or moved to MovedDate at MovedTime. WorkoutID is the workout the
+// occurrence was converted into; a workout in the trash does not count.
+type RecurrenceException struct {
+	Date      string           `json:"date"`
+	Action    RecurrenceAction `json:"action,omitempty"`
+	MovedDate string           `json:"movedDate,omitempty"`
+	MovedTime string           `json:"movedTime,omitempty"`
+	WorkoutID *int64           `json:"workoutId"`
+}
+
+// SeriesException is an exception with the series it belongs to, as read for
+// several series at once.
+type SeriesException struct {
+	RecurringWorkoutID int64
+	RecurrenceException
+}
+
+// AttachExceptions appends each exception to its series among workouts, in
+// order. Exceptions of other series are dropped.
+func AttachExceptions(workouts []RecurringWorkout, exceptions []SeriesException) {
+	index := make(map[int64]int, len(workouts))
+	for i, workout := range workouts {
+		index[workout.RecurringWorkoutID] = i
+	}
+	for _, exception := range exceptions {
+		if i, ok := index[exception.RecurringWorkoutID]; ok {
+			workouts[i].Exceptions = append(workouts[i].Exceptions, exception.RecurrenceException)
+		}
+	}
+}
+
+// Occurrence is a workout of a series planned on Date at Time. Occurrences
+// are identified by OccurrenceDate, the date the rule gives them, which
+// differs from Date once moved.
+type Occurrence struct {
+	RecurringWorkoutID int64  `json:"recurringWorkoutId"`
+	Title              string `json:"title"`
+	OccurrenceDate     string `json:"occurrenceDate"`
+	Date               string `json:"date"`
+	Time               string `json:"time"`
+	Moved              bool   `json:"moved"`
+	WorkoutID          *int64 `json:"workoutId"`
+	Completed          bool   `json:"completed"`
+}
+
+// Occurrences expands the series between from and to inclusive, applying
+// its exceptions, in order of date and time. Only the occurrences of the
+// first limit days that have any are returned.
+func (w *RecurringWorkout) Occurrences(from, to string, limit int) ([]Occurrence, error) {
+	rule, err := rrule.Parse(w.RRule)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse(rrule.DateLayout, w.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	fromDate, err := time.Parse(rrule.DateLayout, from)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := time.Parse(rrule.DateLayout, to)
+	if err != nil {
+		return nil, err
+	}
+	exceptions := make(map[string]RecurrenceException, len(w.Exceptions))
+	for _, exception := range w.Exceptions {
+		exceptions[exception.Date] = exception
+	}
+	occurrence := func(date string) Occurrence {
+		return Occurrence{RecurringWorkoutID: w.RecurringWorkoutID, Title: w.Title, OccurrenceDate: date, Date: date, Time: w.Time}
+	}
+	// Each exception hides at most one occurrence, so this many rule dates
+	// leave limit days or all there are.
+	occurrences := []Occurrence{}
+	for _, date := range rule.Between(start, fromDate, toDate, limit+len(exceptions)) {
+		o := occurrence(date.Format(rrule.DateLayout))
+		exception, ok := exceptions[o.Date]
+		if ok && exception.Action != "" {
+			continue
+		}
+		o.WorkoutID = exception.WorkoutID
+		occurrences = append(occurrences, o)
+	}
+	for _, exception := range w.Exceptions {
+		if exception.Action != RecurrenceMove || exception.MovedDate < from || exception.MovedDate > to {
+			continue
+		}
+		original, err := time.Parse(rrule.DateLayout, exception.Date)
+		if err != nil || !rule.Occurs(start, original) {
+			continue
+		}
+		o := occurrence(exception.Date)
+		o.Date, o.Time, o.Moved, o.WorkoutID = exception.MovedDate, exception.MovedTime, true, exception.WorkoutID
+		occurrences = append(occurrences, o)
+	}
+	slices.SortFunc(occurrences, func(a, b Occurrence) int {
+		return cmp.Or(cmp.Compare(a.Date, b.Date), cmp.Compare(a.Time, b.Time))
+	})
+	days := 0
+	for i, o := range occurrences {
+		if i == 0 || o.Date != occurrences[i-1].Date {
+			if days++; days > limit {
+				occurrences = occurrences[:i]
+				break
+			}
+		}
+	}
+	for i := range occurrences {
+		occurrences[i].Completed = occurrences[i].WorkoutID != nil
+	}
+	return occurrences, nil
+}
+
+type AllowedExercise struct {
+	Name            string
+	Description     string
+	MeasurementType MeasurementType
+	// Bodyweight exercises move the body, so their sets record the load
+	// added to it, or taken off it with a negative weight as on an assisted
+	// machine.
+	Bodyweight bool
+}
+type AllowedExerciseInfo struct {
+	AllowedExerciseId int64           `json:"allowedExerciseId"`
+	Name              string          `json:"name"`
+	Description       string          `json:"description"`
+	MeasurementType   MeasurementType `json:"measurementType"`
+	Bodyweight        bool            `json:"bodyweight"`
+	// OwnerID is the user a custom exercise belongs to, nil for the global
+	// catalog.
+	OwnerID *int64 `json:"ownerId"`
+}
+
+// VisibleTo reports whether userID may record sets of e.
+func (e AllowedExerciseInfo) VisibleTo(userID int64) bool {
+	return e.OwnerID == nil || *e.OwnerID == userID
+}
+
+// MeasurementType says what the sets of an exercise record.
+type MeasurementType string
+
+const (
+	// MeasurementRepsWeight sets record repetitions with a weight.
+	MeasurementRepsWeight MeasurementType = "reps_weight"
+	// MeasurementReps sets record repetitions only.
+	MeasurementReps MeasurementType = "reps"
+	// MeasurementTime sets record a duration, as for a plank.
+	MeasurementTime MeasurementType = "time"
+	// MeasurementDistanceTime sets record a distance and the time it took.
+	MeasurementDistanceTime MeasurementType = "distance_time"
+)
+
+func (t MeasurementType) Valid() bool {
+	switch t {
+	case MeasurementRepsWeight, MeasurementReps, MeasurementTime, MeasurementDistanceTime:
+		return true
+	}
+	return false
+}
+
+// Value stores an exercise without a measurement type as reps and weight.
+func (t MeasurementType) Value() (driver.Value, error) {
+	if t == "" {
+		return string(MeasurementRepsWeight), nil
+	}
+	return string(t), nil
+}
+
+// DefaultLocale is the locale of the names and descriptions stored in the
+// exercise catalog itself. Translations to other locales are optional and
+// fall back to it.
+const DefaultLocale = "ru"
+
+// ExerciseTranslation is the name and description of an exercise in a
+// locale.
+type ExerciseTranslation struct {
+	ExerciseID  int64  `json:"exerciseId"`
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TaxonomyEntry is a muscle group, piece of equipment or movement pattern.
+// Code identifies it in the API; Name is for display.
+type TaxonomyEntry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Taxonomy lists everything exercises can be classified by.
+type Taxonomy struct {
+	MuscleGroups     []TaxonomyEntry `json:"muscleGroups"`
+	Equipment        []TaxonomyEntry `json:"equipment"`
+	MovementPatterns []TaxonomyEntry `json:"movementPatterns"`
+}
+
+// ExerciseTaxonomy classifies an exercise by the codes of its muscle groups,
+// equipment and movement pattern.
+type ExerciseTaxonomy struct {
+	PrimaryMuscles   []string `json:"primaryMuscles"`
+	SecondaryMuscles []string `json:"secondaryMuscles"`
+	Equipment        []string `json:"equipment"`
+	MovementPattern  *string  `json:"movementPattern"`
+}
+
+// CatalogExercise is an exercise with its taxonomy.
+type CatalogExercise struct {
+	AllowedExerciseInfo
+	ExerciseTaxonomy
+}
+
+// CatalogFilter narrows the exercise catalog to exercises with all of the
+// given codes. Empty fields do not filter; PrimaryMuscle only matches
+// exercises that work the muscle as a primary mover.
+type CatalogFilter struct {
+	Muscle          string
+	PrimaryMuscle   bool
+	Equipment       string
+	MovementPattern string
+}
+
+// WorkoutDetails is a workout together with its exercises and their sets.
+type WorkoutDetails struct {
+	WorkoutInfo
+	// BodyWeight is the latest body weight of the user logged on or before
+	// the day of the workout, nil when there is none.
+	BodyWeight *float64
+	Exercises  []WorkoutExerciseDetails
+}
+
+type WorkoutExerciseDetails struct {
+	WorkoutExerciseID int64
+	ExerciseID        int64
+	ExerciseName      string
+	Bodyweight        bool
+	Sets              []SetInfo
+}
+
+// TrashedWorkout is a soft-deleted workout waiting in the trash.
+type TrashedWorkout struct {
+	WorkoutInfo
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type CalendarDay struct {
+	Date     string
+	Workouts []WorkoutInfo
+}
+
+// SearchFilter narrows a full-text search. Empty From/To and a zero
+// ExerciseID do not filter.
+type SearchFilter struct {
+	Query      string
+	From       string
+	To         string
+	ExerciseID int64
+	Limit      int
+}
+
+// SearchResult is a workout matching a search. The snippets mark matched
+// terms with HighlightStart and HighlightEnd. Higher Rank is a better match.
+type SearchResult struct {
+	WorkoutInfo
+	NotesSnippet     string
+	ExercisesSnippet string
+	Rank             float64
+}
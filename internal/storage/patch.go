@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"encoding/json"
+)
+
+// Optional is one field of a JSON merge patch (RFC 7396): absent from the
+// patch, null to clear the field, or a new value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		var zero T
+		o.Null, o.Value = true, zero
+		return nil
+	}
+	o.Null = false
+	return json.Unmarshal(data, &o.Value)
+}
+
+// WorkoutPatch is a merge patch of the fields of a workout its owner may
+// edit. A cleared field is stored as an empty string.
+type WorkoutPatch struct {
+	Date      Optional[string] `json:"date"`
+	StartTime Optional[string] `json:"startTime"`
+	EndTime   Optional[string] `json:"endTime"`
+	Notes     Optional[string] `json:"notes"`
+	Photo     Optional[string] `json:"photo"`
+}
+
+// PatchColumn is a column a patch writes and the value it writes there.
+type PatchColumn struct {
+	Column string
+	Value  any
+}
+
+// workoutPatchFields lists, in a fixed order, every field of WorkoutPatch with
+// its JSON name and column. Backends only ever write the columns named here.
+var workoutPatchFields = []struct {
+	name    string
+	column  string
+	field   func(p *WorkoutPatch) *Optional[string]
+	current func(w WorkoutInfo) string
+}{
+	{"date", "workout_date", func(p *WorkoutPatch) *Optional[string] { return &p.Date }, func(w WorkoutInfo) string { return w.Date }},
+	{"startTime", "workout_start_time", func(p *WorkoutPatch) *Optional[string] { return &p.StartTime }, func(w WorkoutInfo) string { return w.StartTime }},
+	{"endTime", "workout_end_time", func(p *WorkoutPatch) *Optional[string] { return &p.EndTime }, func(w WorkoutInfo) string { return w.EndTime }},
+	{"notes", "notes", func(p *WorkoutPatch) *Optional[string] { return &p.Notes }, func(w WorkoutInfo) string { return w.Notes }},
+	{"photo", "photo", func(p *WorkoutPatch) *Optional[string] { return &p.Photo }, func(w WorkoutInfo) string { return w.Photo }},
+}
+
+// Changes returns p without the fields that already have their patched value
+// in w.
+func (p WorkoutPatch) Changes(w WorkoutInfo) WorkoutPatch {
+	for _, f := range workoutPatchFields {
+		if field := f.field(&p); field.Set && field.Value == f.current(w) {
+			*field = Optional[string]{}
+		}
+	}
+	return p
+}
+
+// Fields returns the JSON names of the fields p sets or clears.
+func (p WorkoutPatch) Fields() []string {
+	fields := []string{}
+	for _, f := range workoutPatchFields {
+		if f.field(&p).Set {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
+
+// Columns returns the columns p writes.
+func (p WorkoutPatch) Columns() []PatchColumn {
+	var columns []PatchColumn
+	for _, f := range workoutPatchFields {
+		if field := f.field(&p); field.Set {
+			columns = append(columns, PatchColumn{Column: f.column, Value: field.Value})
+		}
+	}
+	return columns
+}
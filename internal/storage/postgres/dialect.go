@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"diaryserver/internal/storage"
+)
+
+// dialect is the SQL of PostgreSQL for sqlstore.
+type dialect struct{}
+
+// Rebind numbers the ? placeholders of query $1, $2 and so on. Question marks
+// in string literals are left alone.
+func (dialect) Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (dialect) MapError(err error) error {
+	return mapError(err)
+}
+
+func (dialect) SecondsAgo() string {
+	return `now() - ? * interval '1 second'`
+}
+
+// SearchQuery matches the tsvector documents in workout_search. With
+// exercisesOnly set, a workout whose document matches must also match on its
+// exercise names alone.
+func (dialect) SearchQuery(userID int64, terms []string, exercisesOnly bool) (string, []any, error) {
+	// Every term matches as a prefix and terms are ANDed. SearchTerms leaves
+	// only letters and digits, so nothing needs escaping.
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefixes = append(prefixes, term+":*")
+	}
+	headline := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=12, MinWords=4", storage.HighlightStart, storage.HighlightEnd)
+	notesHeadline, rank, exercisesMatch := `ts_headline('simple', n.notes, q.query, ?)`, `ts_rank(doc.document, q.query)`, ``
+	args := []any{headline}
+	if exercisesOnly {
+		notesHeadline, rank = `''`, `ts_rank(to_tsvector('simple', ex.names), q.query)`
+		exercisesMatch = ` AND to_tsvector('simple', ex.names) @@ q.query`
+		args = nil
+	}
+	query := `SELECT w.workout_id, w.user_id, w.workout_date, w.workout_start_time, w.workout_end_time, w.notes, w.photo, w.version,
+			 ` + notesHeadline + `,
+			 ts_headline('simple', ex.names, q.query, ?),
+			 ` + rank + `
+			 FROM workouts w
+			 -- Encrypted notes cannot be searched.
+			 CROSS JOIN LATERAL (SELECT CASE WHEN w.notes ~ '^enc:v[0-9]+:' THEN '' ELSE COALESCE(w.notes, '') END AS notes) n
+			 CROSS JOIN LATERAL (
+				 SELECT COALESCE(string_agg(ae.name, ' ' ORDER BY we.workout_exercise_id), '') AS names
+				 FROM workout_exercises we
+				 JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+				 WHERE we.workout_id = w.workout_id) ex
+			 JOIN workout_search doc ON doc.workout_id = w.workout_id
+			 CROSS JOIN to_tsquery('simple', ?) AS q(query)
+			 WHERE w.user_id = ? AND w.deleted_at IS NULL AND doc.document @@ q.query` + exercisesMatch
+	args = append(args, "HighlightAll=true, "+headline, strings.Join(prefixes, " & "), userID)
+	return query, args, nil
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"none", "SELECT 1", "SELECT 1"},
+		{"numbered in order", "UPDATE t SET a = ?, b = ? WHERE id = ?", "UPDATE t SET a = $1, b = $2 WHERE id = $3"},
+		{"literal left alone", "SELECT '?' || ? FROM t WHERE c LIKE 'a?%' AND id = ?", "SELECT '?' || $1 FROM t WHERE c LIKE 'a?%' AND id = $2"},
+		{"escaped quote in literal", "SELECT 'it''s ?', ?", "SELECT 'it''s ?', $1"},
+		{"cast", "WHERE (CAST(? AS BIGINT) = 0 OR id = ?)", "WHERE (CAST($1 AS BIGINT) = 0 OR id = $2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (dialect{}).Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"database/sql"
+	"diaryserver/internal/storage"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+)
+
+// mapError translates driver errors into the storage package's errors.
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case codeUniqueViolation:
+			return &storage.ConstraintError{Kind: storage.ErrConflict, Err: err}
+		case codeForeignKeyViolation:
+			return &storage.ConstraintError{Kind: storage.ErrForeignKey, Err: err}
+		}
+	}
+	return err
+}
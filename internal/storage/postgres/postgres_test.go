@@ -0,0 +1,361 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"diaryserver/internal/config"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/postgres"
+	"diaryserver/internal/storage/schema"
+)
+
+// The tests run against a throwaway cluster started with the initdb and
+// pg_ctl found on PATH, listening on a Unix socket only. Every test gets a
+// database of its own. Without the binaries the tests are skipped, unless
+// DIARY_TEST_POSTGRES is set, as it is in CI, where they fail instead.
+var (
+	socketDir  string
+	skipReason string
+	databases  atomic.Int64
+)
+
+func TestMain(m *testing.M) {
+	stop, err := startCluster()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if skipReason != "" && os.Getenv("DIARY_TEST_POSTGRES") != "" {
+		fmt.Fprintln(os.Stderr, "DIARY_TEST_POSTGRES is set but", skipReason)
+		os.Exit(1)
+	}
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
+
+func startCluster() (stop func(), err error) {
+	initdb, initdbErr := exec.LookPath("initdb")
+	pgCtl, pgCtlErr := exec.LookPath("pg_ctl")
+	switch {
+	case initdbErr != nil || pgCtlErr != nil:
+		skipReason = "initdb or pg_ctl is not on PATH"
+		return func() {}, nil
+	case os.Geteuid() == 0:
+		skipReason = "initdb refuses to run as root"
+		return func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "diaryserver-pg")
+	if err != nil {
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %v\n%s", err, out)
+	}
+	options := fmt.Sprintf("-k %s -c listen_addresses=''", dir)
+	start := exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "server.log"), "-o", options, "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %v\n%s", err, out)
+	}
+	socketDir = dir
+	return func() {
+		_ = exec.Command(pgCtl, "-D", data, "-m", "fast", "-w", "stop").Run()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+func dsn(database string) string {
+	return (&url.URL{
+		Scheme:   "postgres",
+		User:     url.User("postgres"),
+		Path:     "/" + database,
+		RawQuery: url.Values{"host": {socketDir}, "sslmode": {"disable"}}.Encode(),
+	}).String()
+}
+
+// newStorage returns a storage on a new, migrated database.
+func newStorage(t *testing.T, keys *encryption.Keyring) *postgres.Storage {
+	t.Helper()
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+	admin, err := sql.Open("postgres", dsn("postgres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	name := fmt.Sprintf("diary_test_%d", databases.Add(1))
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Storage: config.Storage{Driver: config.StorageDriverPostgres, DSN: dsn(name), AutoMigrate: true}}
+	if err := schema.Ensure(cfg, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatal(err)
+	}
+	store, err := postgres.New(cfg.Storage.DSN, 0, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// addUser adds a user and returns its ID.
+func addUser(t *testing.T, store *postgres.Storage, username string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	err := store.AddUser(ctx, storage.User{Username: username, Email: username + "@example.com", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := store.GetUser(ctx, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.UserID
+}
+
+func TestSearchWorkouts(t *testing.T) {
+	ctx := context.Background()
+	store := newStorage(t, nil)
+	userID := addUser(t, store, "searcher")
+	exercises, err := store.GetAllowedExercises(ctx)
+	if err != nil || len(exercises) == 0 {
+		t.Fatalf("catalog is empty: %v", err)
+	}
+	exercise := exercises[0]
+
+	workoutID, err := store.AddWorkout(ctx, storage.Workout{UserID: userID, Date: "2026-01-05", StartTime: "08:00:00", Notes: "heavy morning session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddWorkoutExercise(ctx, storage.WorkoutExercise{WorkoutID: workoutID, ExerciseID: exercise.AllowedExerciseId}); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(query string) []storage.SearchResult {
+		t.Helper()
+		results, err := store.SearchWorkouts(ctx, userID, storage.SearchFilter{Query: query, Limit: 10})
+		if err != nil {
+			t.Fatalf("SearchWorkouts(%q): %v", query, err)
+		}
+		return results
+	}
+	if results := search("heav"); len(results) != 1 || results[0].WorkoutID != workoutID {
+		t.Errorf("search by notes prefix = %+v, want workout %d", results, workoutID)
+	}
+	if results := search(exercise.Name); len(results) != 1 {
+		t.Errorf("search by exercise name %q = %+v, want workout %d", exercise.Name, results, workoutID)
+	}
+
+	// The index follows changes to the notes.
+	workout, err := store.GetWorkoutFromID(ctx, workoutID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patch storage.WorkoutPatch
+	if err := json.Unmarshal([]byte(`{"notes":"light evening session"}`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PartialUpdateWorkout(ctx, workoutID, workout.Version, patch); err != nil {
+		t.Fatal(err)
+	}
+	if results := search("heavy"); len(results) != 0 {
+		t.Errorf("search for replaced notes = %+v, want none", results)
+	}
+	if results := search("evening"); len(results) != 1 {
+		t.Errorf("search for new notes = %+v, want workout %d", results, workoutID)
+	}
+
+	// Other users' workouts are never found.
+	otherID := addUser(t, store, "other")
+	results, err := store.SearchWorkouts(ctx, otherID, storage.SearchFilter{Query: "evening", Limit: 10})
+	if err != nil || len(results) != 0 {
+		t.Errorf("search by another user = %+v, %v, want none", results, err)
+	}
+
+	// Workouts in the trash stay indexed but are not found.
+	if err := store.DeleteWorkouts(ctx, []int64{workoutID}); err != nil {
+		t.Fatal(err)
+	}
+	if results := search("evening"); len(results) != 0 {
+		t.Errorf("search after delete = %+v, want none", results)
+	}
+}
+
+func TestPartialUpdateWorkout(t *testing.T) {
+	ctx := context.Background()
+	store := newStorage(t, nil)
+	userID := addUser(t, store, "patcher")
+	workoutID, err := store.AddWorkout(ctx, storage.Workout{UserID: userID, Date: "2026-01-05", StartTime: "08:00:00", EndTime: "09:00:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := store.GetWorkoutFromID(ctx, workoutID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var patch storage.WorkoutPatch
+	if err := json.Unmarshal([]byte(`{"notes":"patched","endTime":null}`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PartialUpdateWorkout(ctx, workoutID, before.Version, patch); err != nil {
+		t.Fatal(err)
+	}
+	after, err := store.GetWorkoutFromID(ctx, workoutID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Notes != "patched" || after.EndTime != "" || after.StartTime != before.StartTime {
+		t.Errorf("patched workout = %+v", after)
+	}
+	if after.Version != before.Version+1 {
+		t.Errorf("version = %d, want %d", after.Version, before.Version+1)
+	}
+
+	err = store.PartialUpdateWorkout(ctx, workoutID, before.Version, patch)
+	if !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("patch at a stale version: error = %v, want ErrVersionMismatch", err)
+	}
+}
+
+func TestRecurringWorkoutExceptions(t *testing.T) {
+	ctx := context.Background()
+	store := newStorage(t, nil)
+	userID := addUser(t, store, "planner")
+	seriesID, err := store.AddRecurringWorkout(ctx, storage.RecurringWorkout{
+		UserID: userID, Title: "Legs", StartDate: "2026-01-05", RRule: "FREQ=WEEKLY;BYDAY=MO",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exceptions := []storage.RecurrenceException{
+		{Date: "2026-01-12", Action: storage.RecurrenceSkip},
+		{Date: "2026-01-19", Action: storage.RecurrenceMove, MovedDate: "2026-01-20", MovedTime: "18:00:00"},
+	}
+	for _, exception := range exceptions {
+		if err := store.SetRecurrenceException(ctx, userID, seriesID, exception); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	series, err := store.GetRecurringWorkouts(ctx, userID, "2026-01-01", "2026-01-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("series = %+v, want one", series)
+	}
+	got := series[0].Exceptions
+	if len(got) != len(exceptions) {
+		t.Fatalf("exceptions = %+v, want %+v", got, exceptions)
+	}
+	for i := range exceptions {
+		if got[i].Date != exceptions[i].Date || got[i].Action != exceptions[i].Action || got[i].MovedDate != exceptions[i].MovedDate {
+			t.Errorf("exception %d = %+v, want %+v", i, got[i], exceptions[i])
+		}
+	}
+
+	// Exceptions outside the range are not read.
+	series, err = store.GetRecurringWorkouts(ctx, userID, "2026-02-01", "2026-02-28")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || len(series[0].Exceptions) != 0 {
+		t.Errorf("series in February = %+v, want one without exceptions", series)
+	}
+}
+
+func TestEncryptedWorkout(t *testing.T) {
+	ctx := context.Background()
+	encoded, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := encryption.ParseKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newStorage(t, encryption.NewKeyring(master))
+	userID := addUser(t, store, "sealer")
+
+	workoutID, err := store.AddWorkout(ctx, storage.Workout{UserID: userID, Date: "2026-01-05", StartTime: "08:00:00", Notes: "private", Photo: "photo.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workout, err := store.GetWorkoutFromID(ctx, workoutID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workout.Notes != "private" || workout.Photo != "photo.jpg" {
+		t.Errorf("opened workout = %+v", workout)
+	}
+
+	var patch storage.WorkoutPatch
+	if err := json.Unmarshal([]byte(`{"notes":"still private"}`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PartialUpdateWorkout(ctx, workoutID, workout.Version, patch); err != nil {
+		t.Fatal(err)
+	}
+	if workout, err = store.GetWorkoutFromID(ctx, workoutID); err != nil || workout.Notes != "still private" {
+		t.Errorf("patched workout = %+v, %v", workout, err)
+	}
+
+	templateID, err := store.AddTemplate(ctx, storage.WorkoutTemplate{UserID: userID, Name: "Push", Notes: "template notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template, err := store.GetTemplate(ctx, userID, templateID); err != nil || template.Notes != "template notes" {
+		t.Errorf("opened template = %+v, %v", template, err)
+	}
+
+	if err := store.AddBodyWeight(ctx, storage.BodyWeight{UserID: userID, Date: "2026-01-05", Weight: 80.5}); err != nil {
+		t.Fatal(err)
+	}
+	weights, err := store.GetBodyWeights(ctx, userID, "2026-01-01", "2026-01-31")
+	if err != nil || len(weights) != 1 || weights[0].Weight != 80.5 {
+		t.Errorf("opened body weights = %+v, %v", weights, err)
+	}
+
+	// Encrypted notes cannot be searched; exercise names still can.
+	exercises, err := store.GetAllowedExercises(ctx)
+	if err != nil || len(exercises) == 0 {
+		t.Fatalf("catalog is empty: %v", err)
+	}
+	exercise := exercises[0]
+	if _, err := store.AddWorkoutExercise(ctx, storage.WorkoutExercise{WorkoutID: workoutID, ExerciseID: exercise.AllowedExerciseId}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := store.SearchWorkouts(ctx, userID, storage.SearchFilter{Query: "private", Limit: 10})
+	if err != nil || len(results) != 0 {
+		t.Errorf("search by encrypted notes = %+v, %v, want nothing", results, err)
+	}
+	filter := storage.SearchFilter{Query: exercise.Name, From: "2026-01-01", ExerciseID: exercise.AllowedExerciseId, Limit: 10}
+	results, err = store.SearchWorkouts(ctx, userID, filter)
+	if err != nil || len(results) != 1 || results[0].WorkoutID != workoutID {
+		t.Fatalf("search by exercise name = %+v, %v, want workout %d", results, err, workoutID)
+	}
+	if results[0].Notes != "still private" || results[0].NotesSnippet != "" {
+		t.Errorf("result = %+v, want opened notes and no notes snippet", results[0])
+	}
+}
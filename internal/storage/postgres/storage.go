@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"database/sql"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/sqlstore"
+	"fmt"
+	"time"
+)
+
+var _ storage.Storage = (*Storage)(nil)
+
+// Storage is the shared SQL storage running on PostgreSQL.
+type Storage struct {
+	*sqlstore.Storage
+}
+
+// New connects to the database described by dsn. A positive queryTimeout bounds
+// every storage call on top of the caller's context. With keys set, sensitive
+// fields are encrypted at rest.
+func New(dsn string, queryTimeout time.Duration, keys *encryption.Keyring) (*Storage, error) {
+	const op = "storage.postgres.New"
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, mapError(err))
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, mapError(err))
+	}
+	return &Storage{sqlstore.New(db, dialect{}, queryTimeout, keys)}, nil
+}
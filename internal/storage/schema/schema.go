@@ -0,0 +1,213 @@
+package schema
+
+import (
+	"context"
+	"diaryserver/internal/config"
+	"diaryserver/internal/storage/sqlite"
+	"diaryserver/migrations"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// New returns a migrate instance for the configured database that reads the
+// migrations embedded in the binary.
+func New(cfg *config.Config) (*migrate.Migrate, error) {
+	const op = "storage.schema.New"
+	src, err := Source(cfg.Storage.Driver)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return NewWithSource(cfg, src)
+}
+
+// NewWithSource is New with migrations read from src instead.
+func NewWithSource(cfg *config.Config, src source.Driver) (*migrate.Migrate, error) {
+	const op = "storage.schema.NewWithSource"
+	m, err := migrate.NewWithSourceInstance("source", src, DatabaseURL(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return m, nil
+}
+
+// Source returns the embedded migrations for driver. SQLite builds without
+// FTS5 get a plain table in place of the search index, so the schema still
+// migrates and search reports itself unavailable.
+func Source(driver string) (source.Driver, error) {
+	switch driver {
+	case config.StorageDriverPostgres:
+		sub, err := fs.Sub(migrations.Postgres, "postgres")
+		if err != nil {
+			return nil, err
+		}
+		return iofs.New(sub, ".")
+	default:
+		if sqlite.FTS5Enabled {
+			return iofs.New(migrations.SQLite, ".")
+		}
+		sub, err := fs.Sub(migrations.SQLiteNoFTS5, "nofts5")
+		if err != nil {
+			return nil, err
+		}
+		return iofs.New(overlayFS{top: sub, base: migrations.SQLite}, ".")
+	}
+}
+
+// overlayFS serves the files of top in place of the same-named files of base.
+// Directory listings come from base, so top can only replace migrations.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if f, err := o.top.Open(name); err == nil {
+		if info, err := f.Stat(); err == nil && !info.IsDir() {
+			return f, nil
+		}
+		f.Close()
+	}
+	return o.base.Open(name)
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(o.base, name)
+}
+
+// DatabaseURL is the migrate database URL for the configured storage.
+func DatabaseURL(cfg *config.Config) string {
+	if cfg.Storage.Driver == config.StorageDriverPostgres {
+		return cfg.Storage.DSN
+	}
+	return fmt.Sprintf("sqlite3://%s", cfg.StoragePath)
+}
+
+// Latest returns the highest migration version in src.
+func Latest(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// Check reports the schema version of the configured database. It fails when
+// the schema is dirty or newer than the embedded migrations; an older schema
+// is returned as is, with current < expected.
+func Check(cfg *config.Config) (current, expected uint, err error) {
+	const op = "storage.schema.Check"
+	src, err := Source(cfg.Storage.Driver)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	expected, err = Latest(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	m, err := NewWithSource(cfg, src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, expected, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if dirty {
+		return current, expected, fmt.Errorf("%s: schema version %d is dirty", op, current)
+	}
+	if current > expected {
+		return current, expected, fmt.Errorf("%s: schema version %d is newer than the %d this build knows about", op, current, expected)
+	}
+	return current, expected, nil
+}
+
+// Ensure compares the database schema version with the embedded migrations.
+// With autoMigrate set, pending migrations are applied; otherwise any mismatch
+// is an error. A dirty or newer-than-known schema is always an error. A SQLite
+// database first migrated without FTS5 gets its search index built once the
+// build has FTS5.
+func Ensure(cfg *config.Config, log *slog.Logger) error {
+	const op = "storage.schema.Ensure"
+	src, err := Source(cfg.Storage.Driver)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	expected, err := Latest(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	m, err := NewWithSource(cfg, src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if dirty {
+		return fmt.Errorf("%s: schema version %d is dirty, fix it with the migrator's force command", op, current)
+	}
+	if current > expected {
+		return fmt.Errorf("%s: schema version %d is newer than the %d this build knows about", op, current, expected)
+	}
+	switch {
+	case current == expected:
+		log.Info("schema is up to date", slog.Uint64("version", uint64(current)))
+	case !cfg.Storage.AutoMigrate:
+		return fmt.Errorf("%s: schema version %d, expected %d; run the migrator or set storage.auto_migrate", op, current, expected)
+	default:
+		log.Info("applying migrations", slog.Uint64("from", uint64(current)), slog.Uint64("to", uint64(expected)))
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := upgradeSearchIndex(cfg, log); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// upgradeSearchIndex builds the SQLite search index of a database migrated
+// by a build without FTS5 once a build with it opens the database.
+func upgradeSearchIndex(cfg *config.Config, log *slog.Logger) error {
+	if cfg.Storage.Driver == config.StorageDriverPostgres || !sqlite.FTS5Enabled {
+		return nil
+	}
+	s, err := sqlite.New(cfg.StoragePath, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	upgraded, err := s.UpgradeSearchIndex(context.Background())
+	if err != nil {
+		return err
+	}
+	if upgraded {
+		log.Info("built the search index")
+	}
+	return nil
+}
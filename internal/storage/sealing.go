@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"diaryserver/internal/encryption"
+	"fmt"
+	"strconv"
+)
+
+// sealedColumns are the workout columns sealed with the owner's data key when
+// a master key is configured. Values written before that stay readable as
+// plain text until a rekey seals them.
+var sealedColumns = map[string]bool{"notes": true, "photo": true}
+
+// Names of the sealed fields of templates, recurring workouts and body
+// weights; those of workouts are their column names.
+const (
+	templateNotes = "template.notes"
+	seriesNotes   = "recurring_workout.notes"
+	bodyWeight    = "body_weight.weight"
+)
+
+// workoutField is a field of workoutID of userID.
+func workoutField(name string, userID, workoutID int64) encryption.Field {
+	return encryption.Field{Name: name, UserID: userID, RowID: workoutID}
+}
+
+// reseal seals value for field unless it is empty or already sealed in the
+// current format. A value sealed in an older format is opened first.
+func reseal(key []byte, field encryption.Field, value string) (string, error) {
+	if encryption.IsCurrent(value) {
+		return value, nil
+	}
+	plaintext, err := encryption.Open(key, field, value)
+	if err != nil {
+		return "", err
+	}
+	return encryption.Seal(key, field, plaintext)
+}
+
+// Seal seals the sensitive fields of a workout about to be stored as
+// workoutID with key, the data key of its user.
+func (w *Workout) Seal(key []byte, workoutID int64) error {
+	var err error
+	if w.Notes, err = encryption.Seal(key, workoutField("notes", w.UserID, workoutID), w.Notes); err != nil {
+		return err
+	}
+	w.Photo, err = encryption.Seal(key, workoutField("photo", w.UserID, workoutID), w.Photo)
+	return err
+}
+
+// Sealed reports whether any field of w is sealed, so that reading it needs
+// the data key of its user.
+func (w WorkoutInfo) Sealed() bool {
+	return encryption.IsSealed(w.Notes) || encryption.IsSealed(w.Photo)
+}
+
+// Outdated reports whether any field of w that should be sealed is stored as
+// plain text or sealed in an older format.
+func (w WorkoutInfo) Outdated() bool {
+	return !encryption.IsCurrent(w.Notes) || !encryption.IsCurrent(w.Photo)
+}
+
+// Reseal seals the fields of w that are outdated with key.
+func (w *WorkoutInfo) Reseal(key []byte) error {
+	var err error
+	if w.Notes, err = reseal(key, workoutField("notes", w.UserID, w.WorkoutID), w.Notes); err != nil {
+		return err
+	}
+	w.Photo, err = reseal(key, workoutField("photo", w.UserID, w.WorkoutID), w.Photo)
+	return err
+}
+
+// Open opens the sealed fields of a workout that was read with key.
+func (w *WorkoutInfo) Open(key []byte) error {
+	var err error
+	if w.Notes, err = encryption.Open(key, workoutField("notes", w.UserID, w.WorkoutID), w.Notes); err != nil {
+		return err
+	}
+	w.Photo, err = encryption.Open(key, workoutField("photo", w.UserID, w.WorkoutID), w.Photo)
+	return err
+}
+
+// SealColumns seals the sensitive values among the columns of a patch of
+// workoutID. key returns the workout's owner and their data key; it is only
+// called when there is something to seal.
+func SealColumns(columns []PatchColumn, workoutID int64, key func() (int64, []byte, error)) error {
+	var userID int64
+	var dataKey []byte
+	for i, column := range columns {
+		value, _ := column.Value.(string)
+		if !sealedColumns[column.Column] || value == "" {
+			continue
+		}
+		if dataKey == nil {
+			var err error
+			if userID, dataKey, err = key(); err != nil {
+				return err
+			}
+		}
+		sealed, err := encryption.Seal(dataKey, workoutField(column.Column, userID, workoutID), value)
+		if err != nil {
+			return err
+		}
+		columns[i].Value = sealed
+	}
+	return nil
+}
+
+func (t *WorkoutTemplate) notesField() encryption.Field {
+	return encryption.Field{Name: templateNotes, UserID: t.UserID, RowID: t.TemplateID}
+}
+
+// Seal seals the notes of a template about to be written with key, the data
+// key of its user. TemplateID must be set.
+func (t *WorkoutTemplate) Seal(key []byte) error {
+	var err error
+	t.Notes, err = encryption.Seal(key, t.notesField(), t.Notes)
+	return err
+}
+
+// Reseal seals the notes of t with key if they are outdated.
+func (t *WorkoutTemplate) Reseal(key []byte) error {
+	var err error
+	t.Notes, err = reseal(key, t.notesField(), t.Notes)
+	return err
+}
+
+// Open opens the notes of a template that was read with key.
+func (t *WorkoutTemplate) Open(key []byte) error {
+	var err error
+	t.Notes, err = encryption.Open(key, t.notesField(), t.Notes)
+	return err
+}
+
+func (w *RecurringWorkout) notesField() encryption.Field {
+	return encryption.Field{Name: seriesNotes, UserID: w.UserID, RowID: w.RecurringWorkoutID}
+}
+
+// Seal seals the notes of a recurring workout about to be written with key,
+// the data key of its user. RecurringWorkoutID must be set.
+func (w *RecurringWorkout) Seal(key []byte) error {
+	var err error
+	w.Notes, err = encryption.Seal(key, w.notesField(), w.Notes)
+	return err
+}
+
+// Reseal seals the notes of w with key if they are outdated.
+func (w *RecurringWorkout) Reseal(key []byte) error {
+	var err error
+	w.Notes, err = reseal(key, w.notesField(), w.Notes)
+	return err
+}
+
+// Open opens the notes of a recurring workout that was read with key.
+func (w *RecurringWorkout) Open(key []byte) error {
+	var err error
+	w.Notes, err = encryption.Open(key, w.notesField(), w.Notes)
+	return err
+}
+
+// BodyWeightField is the weight of the body weight entry bodyWeightID of
+// userID. Weights are sealed as their decimal text.
+func BodyWeightField(userID, bodyWeightID int64) encryption.Field {
+	return encryption.Field{Name: bodyWeight, UserID: userID, RowID: bodyWeightID}
+}
+
+// FormatBodyWeight returns weight as it is stored in plain text, and sealed.
+func FormatBodyWeight(weight float64) string {
+	return strconv.FormatFloat(weight, 'f', -1, 64)
+}
+
+// ParseBodyWeight reads a stored weight, opening it for field with key if it
+// is sealed.
+func ParseBodyWeight(key []byte, field encryption.Field, value string) (float64, error) {
+	value, err := encryption.Open(key, field, value)
+	if err != nil {
+		return 0, err
+	}
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("stored body weight %q is not a number", value)
+	}
+	return weight, nil
+}
+
+// ResealBodyWeight seals a stored weight for field with key if it is
+// outdated.
+func ResealBodyWeight(key []byte, field encryption.Field, value string) (string, error) {
+	return reseal(key, field, value)
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Matched terms in search snippets are wrapped in these control characters,
+// which never occur in user text, so callers can escape the snippet and then
+// substitute their own markup.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchTerms splits a user query into words. Backends match every word as a
+// prefix; punctuation and operators in the query carry no meaning.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"strings"
+
+	"diaryserver/internal/storage"
+)
+
+// dialect is the SQL of SQLite for sqlstore.
+type dialect struct{}
+
+// Rebind leaves query alone: SQLite takes ? placeholders.
+func (dialect) Rebind(query string) string {
+	return query
+}
+
+func (dialect) MapError(err error) error {
+	return mapError(err)
+}
+
+func (dialect) SecondsAgo() string {
+	return `datetime('now', '-' || ? || ' seconds')`
+}
+
+// SearchQuery matches the FTS5 index. In a build without FTS5 it returns
+// storage.ErrSearchUnavailable.
+func (dialect) SearchQuery(userID int64, terms []string, exercisesOnly bool) (string, []any, error) {
+	if !FTS5Enabled {
+		return "", nil, storage.ErrSearchUnavailable
+	}
+	// Quote every term so FTS5 operators in the input are taken literally,
+	// and match it as a prefix. Terms are ANDed.
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+term+`"*`)
+	}
+	match := strings.Join(quoted, " ")
+	notesSnippet := `snippet(workout_search, 0, ?, ?, '…', 12)`
+	args := []any{storage.HighlightStart, storage.HighlightEnd}
+	if exercisesOnly {
+		match = "exercises : (" + match + ")"
+		notesSnippet, args = `''`, nil
+	}
+	query := `SELECT w.workout_id, w.user_id, w.workout_date, w.workout_start_time, w.workout_end_time, w.notes, w.photo, w.version,
+			 ` + notesSnippet + `,
+			 highlight(workout_search, 1, ?, ?),
+			 -bm25(workout_search)
+			 FROM workout_search
+			 JOIN workouts w ON w.workout_id = workout_search.rowid
+			 WHERE workout_search MATCH ? AND w.user_id = ? AND w.deleted_at IS NULL`
+	args = append(args, storage.HighlightStart, storage.HighlightEnd, match, userID)
+	return query, args, nil
+}
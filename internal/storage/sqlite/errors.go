@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"database/sql"
+	"diaryserver/internal/storage"
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// mapError translates driver errors into the storage package's errors.
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNotFound
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &storage.ConstraintError{Kind: storage.ErrConflict, Err: err}
+		case sqlite3.ErrConstraintForeignKey:
+			return &storage.ConstraintError{Kind: storage.ErrForeignKey, Err: err}
+		}
+	}
+	return err
+}
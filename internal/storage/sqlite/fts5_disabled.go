@@ -0,0 +1,7 @@
+//go:build !(sqlite_fts5 || fts5)
+
+package sqlite
+
+// FTS5Enabled reports whether go-sqlite3 was compiled with FTS5, which the
+// search index needs. It is on when building with -tags sqlite_fts5.
+const FTS5Enabled = false
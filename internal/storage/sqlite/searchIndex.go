@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// UpgradeSearchIndex turns the plain workout_search table that builds without
+// FTS5 migrate to into the FTS5 index, filled from the workouts, and reports
+// whether it did. Encrypted notes are indexed as empty, as the triggers do.
+// It does nothing in a build without FTS5, before migration 12 or when the
+// index is already there. The triggers that keep the table in
+// sync refer to it by name, so they go on working on the index.
+func (s *Storage) UpgradeSearchIndex(ctx context.Context) (bool, error) {
+	const op = "storage.sqlite.UpgradeSearchIndex"
+	if !FTS5Enabled {
+		return false, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to begin transaction: %w", op, mapError(err))
+	}
+	defer tx.Rollback()
+
+	var plain bool
+	query := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'workout_search' AND sql NOT LIKE '%USING fts5%')`
+	if err := tx.QueryRowContext(ctx, query).Scan(&plain); err != nil {
+		return false, fmt.Errorf("%s: %w", op, mapError(err))
+	}
+	if !plain {
+		return false, nil
+	}
+	statements := []string{
+		`DROP TABLE workout_search`,
+		`CREATE VIRTUAL TABLE workout_search USING fts5(
+			notes,
+			exercises,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`,
+		`INSERT INTO workout_search (rowid, notes, exercises)
+		 SELECT w.workout_id, CASE WHEN w.notes GLOB 'enc:v[0-9]*:*' THEN '' ELSE COALESCE(w.notes, '') END,
+			 COALESCE((SELECT group_concat(ae.name, ' ')
+				 FROM workout_exercises we
+				 JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+				 WHERE we.workout_id = w.workout_id), '')
+		 FROM workouts w`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return false, fmt.Errorf("%s: %w", op, mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: failed to commit transaction: %w", op, mapError(err))
+	}
+	return true, nil
+}
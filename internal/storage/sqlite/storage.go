@@ -2,24 +2,49 @@ package sqlite
 
 import (
 	"database/sql"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/sqlstore"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+var _ storage.Storage = (*Storage)(nil)
+
+// busyTimeout is how long, in milliseconds, a connection waits for a lock held
+// by another connection before failing with SQLITE_BUSY.
+const busyTimeout = 5000
+
+// Storage is the shared SQL storage running on SQLite, with what only SQLite
+// has on top.
 type Storage struct {
+	*sqlstore.Storage
 	db *sql.DB
 }
 
-func New(storagePath string) (*Storage, error) {
+// New opens the database at storagePath. A positive queryTimeout bounds every
+// storage call on top of the caller's context. With keys set, sensitive
+// fields are encrypted at rest.
+func New(storagePath string, queryTimeout time.Duration, keys *encryption.Keyring) (*Storage, error) {
 	const op = "storage.sqlite.New"
 	dir := filepath.Dir(storagePath)
 	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 		return nil, fmt.Errorf("%s: failed to create directory: %w", op, err)
 	}
-	db, err := sql.Open("sqlite3", storagePath)
+	db, err := sql.Open("sqlite3", dsn(storagePath))
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, mapError(err))
 	}
-	return &Storage{db: db}, nil
+	return &Storage{Storage: sqlstore.New(db, dialect{}, queryTimeout, keys), db: db}, nil
+}
+
+// dsn builds the connection string for storagePath. The pragmas are passed as
+// driver parameters rather than executed once, because they are per connection
+// and database/sql opens new connections on its own. Transactions take the
+// write lock when they begin, so one that reads before writing, as WithTx
+// units of work do, waits on busyTimeout instead of failing to upgrade.
+func dsn(storagePath string) string {
+	return fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", storagePath, busyTimeout)
 }
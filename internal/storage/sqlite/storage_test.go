@@ -0,0 +1,281 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"diaryserver/internal/config"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"diaryserver/internal/storage/schema"
+	"diaryserver/internal/storage/sqlite"
+)
+
+// env is a migrated database in a temporary file with one user. db reads
+// and writes the file behind the storage's back.
+type env struct {
+	store  *sqlite.Storage
+	db     *sql.DB
+	userID int64
+}
+
+func newEnv(t *testing.T, keys *encryption.Keyring) env {
+	t.Helper()
+	cfg := &config.Config{
+		StoragePath: filepath.Join(t.TempDir(), "diary.db"),
+		Storage:     config.Storage{Driver: config.StorageDriverSQLite, AutoMigrate: true},
+	}
+	if err := schema.Ensure(cfg, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatal(err)
+	}
+	store, err := sqlite.New(cfg.StoragePath, 0, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	db, err := sql.Open("sqlite3", "file:"+cfg.StoragePath+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if err := store.AddUser(ctx, storage.User{Username: "tester", Email: "tester@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := store.GetUser(ctx, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env{store: store, db: db, userID: user.UserID}
+}
+
+func (e env) addWorkout(t *testing.T, notes string) int64 {
+	t.Helper()
+	workoutID, err := e.store.AddWorkout(context.Background(), storage.Workout{UserID: e.userID, Date: "2026-01-05", StartTime: "08:00:00", Notes: notes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return workoutID
+}
+
+func (e env) exerciseID(t *testing.T) int64 {
+	t.Helper()
+	exercises, err := e.store.GetAllowedExercises(context.Background())
+	if err != nil || len(exercises) == 0 {
+		t.Fatalf("catalog is empty: %v", err)
+	}
+	return exercises[0].AllowedExerciseId
+}
+
+func newKeyring(t *testing.T) *encryption.Keyring {
+	t.Helper()
+	encoded, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := encryption.ParseKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encryption.NewKeyring(master)
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		encrypt bool
+		run     func(t *testing.T, e env)
+	}{
+		{
+			name: "WithTx rolls back when fn fails",
+			run: func(t *testing.T, e env) {
+				errFailed := errors.New("failed")
+				exerciseID := e.exerciseID(t)
+				err := e.store.WithTx(ctx, func(tx storage.Repos) error {
+					workoutID, err := tx.AddWorkout(ctx, storage.Workout{UserID: e.userID, Date: "2026-01-05", StartTime: "08:00:00"})
+					if err != nil {
+						return err
+					}
+					if _, err := tx.AddWorkoutExercise(ctx, storage.WorkoutExercise{WorkoutID: workoutID, ExerciseID: exerciseID}); err != nil {
+						return err
+					}
+					return errFailed
+				})
+				if !errors.Is(err, errFailed) {
+					t.Fatalf("WithTx error = %v, want fn's error", err)
+				}
+				if workouts, err := e.store.GetAllWorkouts(ctx, e.userID); err != nil || len(workouts) != 0 {
+					t.Errorf("workouts after rollback = %+v, %v, want none", workouts, err)
+				}
+				var exercises int
+				if err := e.db.QueryRow(`SELECT COUNT(*) FROM workout_exercises`).Scan(&exercises); err != nil || exercises != 0 {
+					t.Errorf("workout exercises after rollback = %d, %v, want none", exercises, err)
+				}
+			},
+		},
+		{
+			name: "WithTx commits when fn succeeds",
+			run: func(t *testing.T, e env) {
+				err := e.store.WithTx(ctx, func(tx storage.Repos) error {
+					_, err := tx.AddWorkout(ctx, storage.Workout{UserID: e.userID, Date: "2026-01-05", StartTime: "08:00:00"})
+					return err
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if workouts, err := e.store.GetAllWorkouts(ctx, e.userID); err != nil || len(workouts) != 1 {
+					t.Errorf("workouts after commit = %+v, %v, want one", workouts, err)
+				}
+			},
+		},
+		{
+			name: "writes at a stale version fail",
+			run: func(t *testing.T, e env) {
+				workoutID := e.addWorkout(t, "")
+				workout, err := e.store.GetWorkoutFromID(ctx, workoutID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var patch storage.WorkoutPatch
+				if err := json.Unmarshal([]byte(`{"notes":"patched"}`), &patch); err != nil {
+					t.Fatal(err)
+				}
+				if err := e.store.PartialUpdateWorkout(ctx, workoutID, workout.Version, patch); err != nil {
+					t.Fatal(err)
+				}
+				stale := workout.Version
+				if err := e.store.PartialUpdateWorkout(ctx, workoutID, stale, patch); !errors.Is(err, storage.ErrVersionMismatch) {
+					t.Errorf("patch at a stale version: error = %v, want ErrVersionMismatch", err)
+				}
+				if err := e.store.DeleteWorkout(ctx, workoutID, stale); !errors.Is(err, storage.ErrVersionMismatch) {
+					t.Errorf("delete at a stale version: error = %v, want ErrVersionMismatch", err)
+				}
+				if err := e.store.DeleteWorkout(ctx, workoutID+1, stale); !errors.Is(err, storage.ErrNotFound) {
+					t.Errorf("delete of a missing workout: error = %v, want ErrNotFound", err)
+				}
+			},
+		},
+		{
+			name: "deleted workouts can be restored until they are purged",
+			run: func(t *testing.T, e env) {
+				workoutID := e.addWorkout(t, "")
+				workout, err := e.store.GetWorkoutFromID(ctx, workoutID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := e.store.DeleteWorkout(ctx, workoutID, workout.Version); err != nil {
+					t.Fatal(err)
+				}
+				if workouts, err := e.store.GetAllWorkouts(ctx, e.userID); err != nil || len(workouts) != 0 {
+					t.Errorf("workouts after delete = %+v, %v, want none", workouts, err)
+				}
+				if trashed, err := e.store.GetDeletedWorkouts(ctx, e.userID); err != nil || len(trashed) != 1 {
+					t.Errorf("trash after delete = %+v, %v, want one workout", trashed, err)
+				}
+
+				if err := e.store.RestoreWorkout(ctx, workoutID); err != nil {
+					t.Fatal(err)
+				}
+				if workouts, err := e.store.GetAllWorkouts(ctx, e.userID); err != nil || len(workouts) != 1 {
+					t.Errorf("workouts after restore = %+v, %v, want one", workouts, err)
+				}
+				if err := e.store.RestoreWorkout(ctx, workoutID); !errors.Is(err, storage.ErrNotFound) {
+					t.Errorf("restore of a workout not in the trash: error = %v, want ErrNotFound", err)
+				}
+
+				if err := e.store.DeleteWorkouts(ctx, []int64{workoutID}); err != nil {
+					t.Fatal(err)
+				}
+				purged, err := e.store.PurgeDeletedWorkouts(ctx, time.Hour)
+				if err != nil || len(purged) != 0 {
+					t.Errorf("purge of a fresh deletion = %+v, %v, want none", purged, err)
+				}
+				if _, err := e.db.Exec(`UPDATE workouts SET deleted_at = datetime('now', '-2 hours') WHERE workout_id = ?`, workoutID); err != nil {
+					t.Fatal(err)
+				}
+				purged, err = e.store.PurgeDeletedWorkouts(ctx, time.Hour)
+				if err != nil || len(purged) != 1 || purged[0].WorkoutID != workoutID {
+					t.Errorf("purge = %+v, %v, want workout %d", purged, err, workoutID)
+				}
+				if err := e.store.RestoreWorkout(ctx, workoutID); !errors.Is(err, storage.ErrNotFound) {
+					t.Errorf("restore of a purged workout: error = %v, want ErrNotFound", err)
+				}
+			},
+		},
+		{
+			name: "foreign keys are enforced",
+			run: func(t *testing.T, e env) {
+				_, err := e.store.AddWorkout(ctx, storage.Workout{UserID: e.userID + 1, Date: "2026-01-05", StartTime: "08:00:00"})
+				if !errors.Is(err, storage.ErrForeignKey) {
+					t.Errorf("workout of a missing user: error = %v, want ErrForeignKey", err)
+				}
+				workoutID := e.addWorkout(t, "")
+				_, err = e.store.AddWorkoutExercise(ctx, storage.WorkoutExercise{WorkoutID: workoutID, ExerciseID: -1})
+				if !errors.Is(err, storage.ErrForeignKey) {
+					t.Errorf("workout exercise of a missing exercise: error = %v, want ErrForeignKey", err)
+				}
+			},
+		},
+		{
+			name:    "sensitive fields are sealed at rest",
+			encrypt: true,
+			run: func(t *testing.T, e env) {
+				workoutID := e.addWorkout(t, "private")
+				var stored string
+				if err := e.db.QueryRow(`SELECT notes FROM workouts WHERE workout_id = ?`, workoutID).Scan(&stored); err != nil {
+					t.Fatal(err)
+				}
+				if !encryption.IsCurrent(stored) || stored == "" || strings.Contains(stored, "private") {
+					t.Errorf("stored notes = %q, want them sealed", stored)
+				}
+				workout, err := e.store.GetWorkoutFromID(ctx, workoutID)
+				if err != nil || workout.Notes != "private" {
+					t.Errorf("opened workout = %+v, %v", workout, err)
+				}
+
+				if err := e.store.AddBodyWeight(ctx, storage.BodyWeight{UserID: e.userID, Date: "2026-01-05", Weight: 80.5}); err != nil {
+					t.Fatal(err)
+				}
+				if err := e.db.QueryRow(`SELECT weight FROM body_weights WHERE user_id = ?`, e.userID).Scan(&stored); err != nil {
+					t.Fatal(err)
+				}
+				if !encryption.IsSealed(stored) {
+					t.Errorf("stored body weight = %q, want it sealed", stored)
+				}
+				weights, err := e.store.GetBodyWeights(ctx, e.userID, "2026-01-01", "2026-01-31")
+				if err != nil || len(weights) != 1 || weights[0].Weight != 80.5 {
+					t.Errorf("opened body weights = %+v, %v", weights, err)
+				}
+
+				// Sealed values are bound to their row: one copied to another
+				// workout does not open.
+				otherID := e.addWorkout(t, "")
+				if _, err := e.db.Exec(`UPDATE workouts SET notes = (SELECT notes FROM workouts WHERE workout_id = ?) WHERE workout_id = ?`, workoutID, otherID); err != nil {
+					t.Fatal(err)
+				}
+				if workout, err := e.store.GetWorkoutFromID(ctx, otherID); err == nil {
+					t.Errorf("workout with copied notes = %+v, want an error", workout)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys *encryption.Keyring
+			if tt.encrypt {
+				keys = newKeyring(t)
+			}
+			tt.run(t, newEnv(t, keys))
+		})
+	}
+}
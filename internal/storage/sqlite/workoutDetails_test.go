@@ -0,0 +1,72 @@
+package sqlite_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"diaryserver/internal/config"
+	"diaryserver/internal/seed"
+	"diaryserver/internal/storage/schema"
+	"diaryserver/internal/storage/sqlite"
+)
+
+// BenchmarkLoadTrainingSingle compares reading a workout of the load-test
+// dataset the way LoadTrainingSingle used to, one query per exercise, with
+// the single JOIN of GetWorkoutDetails.
+func BenchmarkLoadTrainingSingle(b *testing.B) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		StoragePath: filepath.Join(b.TempDir(), "bench.db"),
+		Storage:     config.Storage{Driver: config.StorageDriverSQLite, AutoMigrate: true},
+	}
+	if err := schema.Ensure(cfg, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		b.Fatal(err)
+	}
+	store, err := sqlite.New(cfg.StoragePath, 0, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer store.Close()
+	if err := seed.Apply(ctx, store, "load-test"); err != nil {
+		b.Fatal(err)
+	}
+	user, err := store.GetUser(ctx, "loadtest01")
+	if err != nil {
+		b.Fatal(err)
+	}
+	workouts, err := store.GetAllWorkouts(ctx, user.UserID)
+	if err != nil || len(workouts) == 0 {
+		b.Fatalf("no load-test workouts: %v", err)
+	}
+	workoutID := workouts[len(workouts)/2].WorkoutID
+
+	b.Run("N+1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.GetWorkoutFromID(ctx, workoutID); err != nil {
+				b.Fatal(err)
+			}
+			exercises, err := store.GetWorkoutExercises(ctx, workoutID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, exercise := range exercises {
+				if _, err := store.GetAllowedExercise(ctx, exercise.ExerciseID); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := store.GetSets(ctx, exercise.WorkoutExerciseID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("JOIN", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.GetWorkoutDetails(ctx, workoutID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddAllowedExercise(ctx context.Context, exercise storage.AllowedExercise) error {
+	const op = "storage.sqlstore.AddAllowedExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if exercise.Name == "" {
+		return fmt.Errorf("%s: name is required", op)
+	}
+	query := `INSERT INTO allowed_exercises (name, description, measurement_type, bodyweight) VALUES (?, ?, ?, ?)`
+
+	_, err := s.conn.ExecContext(ctx, query, exercise.Name, exercise.Description, exercise.MeasurementType, exercise.Bodyweight)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
+func (s *Storage) AddAllowedExercises(ctx context.Context, exercises []storage.AllowedExercise) error {
+	const op = "storage.sqlstore.AddAllowedExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO allowed_exercises (name, description, measurement_type, bodyweight) VALUES (?, ?, ?, ?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, exercise := range exercises {
+		if exercise.Name == "" {
+			return fmt.Errorf("%s: name is required", op)
+		}
+		_, err := stmt.ExecContext(ctx, exercise.Name, exercise.Description, exercise.MeasurementType, exercise.Bodyweight)
+		if err != nil {
+			return fmt.Errorf("%s: failed to add exercise %s: %w", op, exercise.Name, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+func (s *Storage) DeleteAllowedExercise(ctx context.Context, name string) error {
+	const op = "storage.sqlstore.DeleteAllowedExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM allowed_exercises WHERE name = ? AND owner_id IS NULL`
+
+	_, err := s.conn.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteAllowedExercises(ctx context.Context, names []string) error {
+	const op = "storage.sqlstore.DeleteAllowedExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM allowed_exercises WHERE name = ? AND owner_id IS NULL`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, name := range names {
+		_, err := stmt.ExecContext(ctx, name)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete exercise %s: %w", op, name, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetAllowedExercise(ctx context.Context, id int64) (storage.AllowedExerciseInfo, error) {
+	const op = "storage.sqlstore.GetAllowedExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT exercise_id, name, description, measurement_type, bodyweight, owner_id FROM allowed_exercises WHERE exercise_id = ?`
+
+	var exercise storage.AllowedExerciseInfo
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(&exercise.AllowedExerciseId, &exercise.Name, &exercise.Description, &exercise.MeasurementType, &exercise.Bodyweight, &exercise.OwnerID)
+	if err != nil {
+		return storage.AllowedExerciseInfo{}, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return exercise, nil
+}
+
+func (s *Storage) GetAllowedExercises(ctx context.Context) ([]storage.AllowedExerciseInfo, error) {
+	const op = "storage.sqlstore.GetAllowedExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT exercise_id, name, description, measurement_type, bodyweight FROM allowed_exercises WHERE owner_id IS NULL`
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var exercises []storage.AllowedExerciseInfo
+	for rows.Next() {
+		var exercise storage.AllowedExerciseInfo
+		if err := rows.Scan(&exercise.AllowedExerciseId, &exercise.Name, &exercise.Description, &exercise.MeasurementType, &exercise.Bodyweight); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return exercises, nil
+}
@@ -0,0 +1,59 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) AddBlacklistedToken(ctx context.Context, token string, expirationTime time.Time) error {
+	const op = "storage.sqlstore.AddBlacklistedToken"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO blacklisted_tokens (token, expiration_time)
+		VALUES (?, ?)`
+	_, err := s.conn.ExecContext(ctx, query, token, expirationTime)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
+	const op = "storage.sqlstore.IsTokenBlacklisted"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*) 
+		FROM blacklisted_tokens 
+		WHERE token = ? AND expiration_time > CURRENT_TIMESTAMP`
+
+	var count int
+	err := s.conn.QueryRowContext(ctx, query, token).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return count > 0, nil
+}
+
+func (s *Storage) RemoveExpiredTokens(ctx context.Context) error {
+	const op = "storage.sqlstore.RemoveExpiredTokens"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM blacklisted_tokens 
+		WHERE expiration_time <= CURRENT_TIMESTAMP`
+
+	_, err := s.conn.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddBodyWeight(ctx context.Context, entry storage.BodyWeight) error {
+	const op = "storage.sqlstore.AddBodyWeight"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if entry.Date == "" || entry.Weight <= 0 {
+		return fmt.Errorf("%s: date and a positive weight are required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// A sealed weight is bound to the entry's ID, so it is stored once the ID
+	// is known.
+	weight := storage.FormatBodyWeight(entry.Weight)
+	if s.keys != nil {
+		weight = ""
+	}
+	query := `INSERT INTO body_weights (user_id, measured_on, weight) VALUES (?, ?, ?)
+			 ON CONFLICT (user_id, measured_on) DO UPDATE SET weight = excluded.weight
+			 RETURNING body_weight_id`
+	var bodyWeightID int64
+	if err := tx.QueryRowContext(ctx, query, entry.UserID, entry.Date, weight).Scan(&bodyWeightID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if s.keys != nil {
+		key, err := s.dataKey(ctx, tx, entry.UserID, true)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		sealed, err := encryption.Seal(key, storage.BodyWeightField(entry.UserID, bodyWeightID), storage.FormatBodyWeight(entry.Weight))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE body_weights SET weight = ? WHERE body_weight_id = ?`, sealed, bodyWeightID); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteBodyWeight(ctx context.Context, userID int64, date string) error {
+	const op = "storage.sqlstore.DeleteBodyWeight"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM body_weights WHERE user_id = ? AND measured_on = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, userID, date)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: body weight %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetBodyWeights(ctx context.Context, userID int64, from, to string) ([]storage.BodyWeight, error) {
+	const op = "storage.sqlstore.GetBodyWeights"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT body_weight_id, user_id, measured_on, weight FROM body_weights
+			 WHERE user_id = ? AND measured_on BETWEEN ? AND ?
+			 ORDER BY measured_on`
+
+	rows, err := s.conn.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var weights []string
+	entries := []storage.BodyWeight{}
+	for rows.Next() {
+		var entry storage.BodyWeight
+		var bodyWeightID int64
+		var weight string
+		if err := rows.Scan(&bodyWeightID, &entry.UserID, &entry.Date, &weight); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		ids, weights = append(ids, bodyWeightID), append(weights, weight)
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range entries {
+		if entries[i].Weight, err = s.openBodyWeight(ctx, s.conn, userID, ids[i], weights[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return entries, nil
+}
+
+// bodyWeightOn returns the latest body weight of userID logged on or before
+// date, nil when there is none.
+func (s *Storage) bodyWeightOn(ctx context.Context, q queryer, userID int64, date string) (*float64, error) {
+	query := `SELECT body_weight_id, weight FROM body_weights
+			 WHERE user_id = ? AND measured_on <= ?
+			 ORDER BY measured_on DESC LIMIT 1`
+	var bodyWeightID int64
+	var value string
+	err := q.QueryRowContext(ctx, query, userID, date).Scan(&bodyWeightID, &value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	weight, err := s.openBodyWeight(ctx, q, userID, bodyWeightID, value)
+	if err != nil {
+		return nil, err
+	}
+	return &weight, nil
+}
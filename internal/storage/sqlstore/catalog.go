@@ -0,0 +1,247 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) GetTaxonomy(ctx context.Context) (*storage.Taxonomy, error) {
+	const op = "storage.sqlstore.GetTaxonomy"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	var taxonomy storage.Taxonomy
+	for _, list := range []struct {
+		query   string
+		entries *[]storage.TaxonomyEntry
+	}{
+		{`SELECT code, name FROM muscle_groups ORDER BY muscle_group_id`, &taxonomy.MuscleGroups},
+		{`SELECT code, name FROM equipment ORDER BY equipment_id`, &taxonomy.Equipment},
+		{`SELECT code, name FROM movement_patterns ORDER BY movement_pattern_id`, &taxonomy.MovementPatterns},
+	} {
+		rows, err := s.conn.QueryContext(ctx, list.query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		*list.entries = []storage.TaxonomyEntry{}
+		for rows.Next() {
+			var entry storage.TaxonomyEntry
+			if err := rows.Scan(&entry.Code, &entry.Name); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+			}
+			*list.entries = append(*list.entries, entry)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	return &taxonomy, nil
+}
+
+func (s *Storage) GetCatalog(ctx context.Context, userID int64, filter storage.CatalogFilter) ([]storage.CatalogExercise, error) {
+	const op = "storage.sqlstore.GetCatalog"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT ae.exercise_id, ae.name, ae.description, ae.measurement_type, ae.bodyweight, ae.owner_id, mp.code
+			 FROM allowed_exercises ae
+			 LEFT JOIN movement_patterns mp ON mp.movement_pattern_id = ae.movement_pattern_id
+			 WHERE (ae.owner_id IS NULL OR ae.owner_id = ?)`
+	args := []any{userID}
+	if filter.Muscle != "" {
+		muscles := `SELECT 1 FROM exercise_muscles em JOIN muscle_groups mg ON mg.muscle_group_id = em.muscle_group_id
+			 WHERE em.exercise_id = ae.exercise_id`
+		if filter.PrimaryMuscle {
+			muscles += ` AND em.role = 'primary'`
+		}
+		args = append(args, filter.Muscle)
+		query += ` AND EXISTS (` + muscles + ` AND mg.code = ?)`
+	}
+	if filter.Equipment != "" {
+		args = append(args, filter.Equipment)
+		query += ` AND EXISTS (SELECT 1 FROM exercise_equipment ee JOIN equipment eq ON eq.equipment_id = ee.equipment_id WHERE ee.exercise_id = ae.exercise_id AND eq.code = ?)`
+	}
+	if filter.MovementPattern != "" {
+		args = append(args, filter.MovementPattern)
+		query += ` AND mp.code = ?`
+	}
+	query += ` ORDER BY ae.owner_id IS NOT NULL, ae.name`
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	exercises := []storage.CatalogExercise{}
+	byID := make(map[int64]*storage.CatalogExercise)
+	for rows.Next() {
+		var exercise storage.CatalogExercise
+		err := rows.Scan(
+			&exercise.AllowedExerciseId,
+			&exercise.Name,
+			&exercise.Description,
+			&exercise.MeasurementType,
+			&exercise.Bodyweight,
+			&exercise.OwnerID,
+			&exercise.MovementPattern,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		exercise.PrimaryMuscles = []string{}
+		exercise.SecondaryMuscles = []string{}
+		exercise.Equipment = []string{}
+		exercises = append(exercises, exercise)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	rows.Close()
+	for i := range exercises {
+		byID[exercises[i].AllowedExerciseId] = &exercises[i]
+	}
+
+	muscles := `SELECT em.exercise_id, mg.code, em.role
+			 FROM exercise_muscles em
+			 JOIN muscle_groups mg ON mg.muscle_group_id = em.muscle_group_id
+			 JOIN allowed_exercises ae ON ae.exercise_id = em.exercise_id
+			 WHERE ae.owner_id IS NULL OR ae.owner_id = ?
+			 ORDER BY em.exercise_id, mg.muscle_group_id`
+	err = s.scanCodes(ctx, muscles, userID, func(exerciseID int64, code, role string) {
+		if exercise, ok := byID[exerciseID]; ok {
+			if role == "primary" {
+				exercise.PrimaryMuscles = append(exercise.PrimaryMuscles, code)
+			} else {
+				exercise.SecondaryMuscles = append(exercise.SecondaryMuscles, code)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	equipment := `SELECT ee.exercise_id, eq.code, ''
+			 FROM exercise_equipment ee
+			 JOIN equipment eq ON eq.equipment_id = ee.equipment_id
+			 JOIN allowed_exercises ae ON ae.exercise_id = ee.exercise_id
+			 WHERE ae.owner_id IS NULL OR ae.owner_id = ?
+			 ORDER BY ee.exercise_id, eq.equipment_id`
+	err = s.scanCodes(ctx, equipment, userID, func(exerciseID int64, code, _ string) {
+		if exercise, ok := byID[exerciseID]; ok {
+			exercise.Equipment = append(exercise.Equipment, code)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exercises, nil
+}
+
+// scanCodes runs a query of exercise IDs, codes and a detail for userID and
+// hands every row to add.
+func (s *Storage) scanCodes(ctx context.Context, query string, userID int64, add func(exerciseID int64, code, detail string)) error {
+	rows, err := s.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return s.mapError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var exerciseID int64
+		var code, detail string
+		if err := rows.Scan(&exerciseID, &code, &detail); err != nil {
+			return s.mapError(err)
+		}
+		add(exerciseID, code, detail)
+	}
+	return s.mapError(rows.Err())
+}
+
+func (s *Storage) SetExerciseTaxonomy(ctx context.Context, ownerID, exerciseID int64, taxonomy storage.ExerciseTaxonomy) error {
+	const op = "storage.sqlstore.SetExerciseTaxonomy"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var patternID sql.NullInt64
+	if taxonomy.MovementPattern != nil {
+		query := `SELECT movement_pattern_id FROM movement_patterns WHERE code = ?`
+		err := tx.QueryRowContext(ctx, query, *taxonomy.MovementPattern).Scan(&patternID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s: movement pattern %q: %w", op, *taxonomy.MovementPattern, storage.ErrForeignKey)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+	query := `UPDATE allowed_exercises SET movement_pattern_id = ? WHERE exercise_id = ? AND owner_id = ?`
+	result, err := tx.ExecContext(ctx, query, patternID, exerciseID, ownerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: exercise %w", op, storage.ErrNotFound)
+	}
+
+	for _, query := range []string{
+		`DELETE FROM exercise_muscles WHERE exercise_id = ?`,
+		`DELETE FROM exercise_equipment WHERE exercise_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, exerciseID); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+	// Each insert finds its row by code, so inserting nothing means the code
+	// is unknown.
+	insert := func(query, kind, code string, args ...any) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return s.mapError(err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return s.mapError(err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("%s %q: %w", kind, code, storage.ErrForeignKey)
+		}
+		return nil
+	}
+	muscles := `INSERT INTO exercise_muscles (exercise_id, muscle_group_id, role)
+			 SELECT CAST(? AS BIGINT), muscle_group_id, CAST(? AS TEXT) FROM muscle_groups WHERE code = ?`
+	for role, codes := range map[string][]string{"primary": taxonomy.PrimaryMuscles, "secondary": taxonomy.SecondaryMuscles} {
+		for _, code := range codes {
+			if err := insert(muscles, "muscle group", code, exerciseID, role, code); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+	equipment := `INSERT INTO exercise_equipment (exercise_id, equipment_id)
+			 SELECT CAST(? AS BIGINT), equipment_id FROM equipment WHERE code = ?`
+	for _, code := range taxonomy.Equipment {
+		if err := insert(equipment, "equipment", code, exerciseID, code); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
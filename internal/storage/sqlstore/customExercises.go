@@ -0,0 +1,131 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddCustomExercise(ctx context.Context, ownerID int64, exercise storage.AllowedExercise) (int64, error) {
+	const op = "storage.sqlstore.AddCustomExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if exercise.Name == "" {
+		return 0, fmt.Errorf("%s: name is required", op)
+	}
+	query := `INSERT INTO allowed_exercises (name, description, measurement_type, bodyweight, owner_id) VALUES (?, ?, ?, ?, ?)
+			 RETURNING exercise_id`
+	var exerciseID int64
+	err := s.conn.QueryRowContext(ctx, query, exercise.Name, exercise.Description, exercise.MeasurementType, exercise.Bodyweight, ownerID).Scan(&exerciseID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return exerciseID, nil
+}
+
+func (s *Storage) GetCustomExercises(ctx context.Context, ownerID int64) ([]storage.AllowedExerciseInfo, error) {
+	const op = "storage.sqlstore.GetCustomExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT exercise_id, name, description, measurement_type, bodyweight, owner_id FROM allowed_exercises
+			 WHERE owner_id = ? ORDER BY name`
+
+	rows, err := s.conn.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	exercises := []storage.AllowedExerciseInfo{}
+	for rows.Next() {
+		var exercise storage.AllowedExerciseInfo
+		if err := rows.Scan(&exercise.AllowedExerciseId, &exercise.Name, &exercise.Description, &exercise.MeasurementType, &exercise.Bodyweight, &exercise.OwnerID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return exercises, nil
+}
+
+func (s *Storage) UpdateCustomExercise(ctx context.Context, ownerID, exerciseID int64, exercise storage.AllowedExercise) error {
+	const op = "storage.sqlstore.UpdateCustomExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if exercise.Name == "" {
+		return fmt.Errorf("%s: name is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var current storage.MeasurementType
+	query := `SELECT measurement_type FROM allowed_exercises WHERE exercise_id = ? AND owner_id = ?`
+	err = tx.QueryRowContext(ctx, query, exerciseID, ownerID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s: exercise %w", op, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	// Recorded and template sets were checked against the measurement type,
+	// so it stays while any are left.
+	if measurement, _ := exercise.MeasurementType.Value(); measurement != string(current) {
+		var used bool
+		query := `SELECT EXISTS (SELECT 1 FROM workout_exercises WHERE exercise_id = ?)
+				 OR EXISTS (SELECT 1 FROM template_exercises WHERE exercise_id = ?)`
+		if err := tx.QueryRowContext(ctx, query, exerciseID, exerciseID).Scan(&used); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		if used {
+			return fmt.Errorf("%s: measurement type of an exercise in use: %w", op, storage.ErrConflict)
+		}
+	}
+
+	query = `UPDATE allowed_exercises SET name = ?, description = ?, measurement_type = ?, bodyweight = ?
+			 WHERE exercise_id = ? AND owner_id = ?`
+	_, err = tx.ExecContext(ctx, query, exercise.Name, exercise.Description, exercise.MeasurementType, exercise.Bodyweight, exerciseID, ownerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteCustomExercise deletes an exercise of ownerID. It fails with
+// ErrForeignKey while workouts or templates still use the exercise.
+func (s *Storage) DeleteCustomExercise(ctx context.Context, ownerID, exerciseID int64) error {
+	const op = "storage.sqlstore.DeleteCustomExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM allowed_exercises WHERE exercise_id = ? AND owner_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, exerciseID, ownerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: exercise %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
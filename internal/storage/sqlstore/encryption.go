@@ -0,0 +1,426 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+)
+
+// dataKey returns the data key of userID, reading it through q so that it
+// sees keys created earlier in the same transaction. With create set, a
+// missing key is created.
+func (s *Storage) dataKey(ctx context.Context, q queryer, userID int64, create bool) ([]byte, error) {
+	if s.keys == nil {
+		return nil, encryption.ErrNoMasterKey
+	}
+	if key, ok := s.keys.Get(userID); ok {
+		return key, nil
+	}
+	master := s.keys.Master()
+
+	query := `SELECT wrapped_key, master_key_id FROM user_keys WHERE user_id = ?`
+	var wrapped []byte
+	var masterKeyID string
+	err := q.QueryRowContext(ctx, query, userID).Scan(&wrapped, &masterKeyID)
+	if err == sql.ErrNoRows && create {
+		// Another writer may create the key first; the key is whichever insert
+		// won.
+		_, newWrapped, newErr := master.NewDataKey()
+		if newErr != nil {
+			return nil, newErr
+		}
+		insert := `INSERT INTO user_keys (user_id, wrapped_key, master_key_id) VALUES (?, ?, ?) ON CONFLICT (user_id) DO NOTHING`
+		if _, newErr = q.ExecContext(ctx, insert, userID, newWrapped, master.ID()); newErr != nil {
+			return nil, s.mapError(newErr)
+		}
+		err = q.QueryRowContext(ctx, query, userID).Scan(&wrapped, &masterKeyID)
+	}
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("data key of user %d %w", userID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+	key, err := s.keys.Unwrap(userID, wrapped, masterKeyID)
+	if err != nil {
+		return nil, err
+	}
+	// Outside a transaction the key is committed; inside one it could still be
+	// rolled back with it.
+	if s.tx == nil && q == s.conn {
+		s.keys.Put(userID, key)
+	}
+	return key, nil
+}
+
+// sealsWorkout reports whether workout has fields to seal. They are bound to
+// the workout's ID, so such a workout is inserted without them and
+// sealWorkout stores them once the ID is known.
+func (s *Storage) sealsWorkout(workout storage.Workout) bool {
+	return s.keys != nil && (workout.Notes != "" || workout.Photo != "")
+}
+
+// sealWorkout stores the sealed fields of workout, inserted as workoutID.
+func (s *Storage) sealWorkout(ctx context.Context, q queryer, workoutID int64, workout storage.Workout) error {
+	key, err := s.dataKey(ctx, q, workout.UserID, true)
+	if err != nil {
+		return err
+	}
+	if err := workout.Seal(key, workoutID); err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `UPDATE workouts SET notes = ?, photo = ? WHERE workout_id = ?`, workout.Notes, workout.Photo, workoutID)
+	if err != nil {
+		return s.mapError(err)
+	}
+	return nil
+}
+
+// sealColumns seals the sensitive values among the columns of a patch of
+// workoutID.
+func (s *Storage) sealColumns(ctx context.Context, q queryer, workoutID int64, columns []storage.PatchColumn) error {
+	if s.keys == nil {
+		return nil
+	}
+	return storage.SealColumns(columns, workoutID, func() (int64, []byte, error) {
+		var userID int64
+		err := q.QueryRowContext(ctx, `SELECT user_id FROM workouts WHERE workout_id = ?`, workoutID).Scan(&userID)
+		if err == sql.ErrNoRows {
+			return 0, nil, fmt.Errorf("workout %w", storage.ErrNotFound)
+		}
+		if err != nil {
+			return 0, nil, s.mapError(err)
+		}
+		key, err := s.dataKey(ctx, q, userID, true)
+		return userID, key, err
+	})
+}
+
+// openWorkout opens the sealed fields of a workout that was read. Call it
+// after the rows it was scanned from are done, as it may query q.
+func (s *Storage) openWorkout(ctx context.Context, q queryer, workout *storage.WorkoutInfo) error {
+	if !workout.Sealed() {
+		return nil
+	}
+	key, err := s.dataKey(ctx, q, workout.UserID, false)
+	if err != nil {
+		return err
+	}
+	return workout.Open(key)
+}
+
+// sealTemplate seals the notes of a template about to be written; its
+// TemplateID and UserID must be set.
+func (s *Storage) sealTemplate(ctx context.Context, q queryer, template *storage.WorkoutTemplate) error {
+	if s.keys == nil || template.Notes == "" {
+		return nil
+	}
+	key, err := s.dataKey(ctx, q, template.UserID, true)
+	if err != nil {
+		return err
+	}
+	return template.Seal(key)
+}
+
+// openTemplate opens the notes of a template that was read. Call it after
+// the rows it was scanned from are done, as it may query q.
+func (s *Storage) openTemplate(ctx context.Context, q queryer, template *storage.WorkoutTemplate) error {
+	if !encryption.IsSealed(template.Notes) {
+		return nil
+	}
+	key, err := s.dataKey(ctx, q, template.UserID, false)
+	if err != nil {
+		return err
+	}
+	return template.Open(key)
+}
+
+// sealRecurringWorkout seals the notes of a recurring workout about to be
+// written; its RecurringWorkoutID and UserID must be set.
+func (s *Storage) sealRecurringWorkout(ctx context.Context, q queryer, workout *storage.RecurringWorkout) error {
+	if s.keys == nil || workout.Notes == "" {
+		return nil
+	}
+	key, err := s.dataKey(ctx, q, workout.UserID, true)
+	if err != nil {
+		return err
+	}
+	return workout.Seal(key)
+}
+
+// openRecurringWorkout opens the notes of a recurring workout that was read.
+// Call it after the rows it was scanned from are done, as it may query q.
+func (s *Storage) openRecurringWorkout(ctx context.Context, q queryer, workout *storage.RecurringWorkout) error {
+	if !encryption.IsSealed(workout.Notes) {
+		return nil
+	}
+	key, err := s.dataKey(ctx, q, workout.UserID, false)
+	if err != nil {
+		return err
+	}
+	return workout.Open(key)
+}
+
+// openBodyWeight reads the stored weight of the body weight entry
+// bodyWeightID of userID. Call it after the rows it was scanned from are
+// done, as it may query q.
+func (s *Storage) openBodyWeight(ctx context.Context, q queryer, userID, bodyWeightID int64, value string) (float64, error) {
+	var key []byte
+	if encryption.IsSealed(value) {
+		var err error
+		if key, err = s.dataKey(ctx, q, userID, false); err != nil {
+			return 0, err
+		}
+	}
+	return storage.ParseBodyWeight(key, storage.BodyWeightField(userID, bodyWeightID), value)
+}
+
+// Rekey rewraps every data key with next and seals the fields still stored as
+// plain text or sealed in an older format, in one transaction. Keys already wrapped by next are
+// left alone, so an interrupted rekey can be run again.
+func (s *Storage) Rekey(ctx context.Context, next *encryption.Key) (encryption.RekeyResult, error) {
+	const op = "storage.sqlstore.Rekey"
+	var result encryption.RekeyResult
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("%s: failed to begin transaction: %w", op, s.mapError(err))
+	}
+	defer sqlTx.Rollback()
+	// rekeyed seals with the rewrapped keys.
+	rekeyed := s.inTx(sqlTx)
+	rekeyed.keys = encryption.NewKeyring(next)
+	tx := rekeyed.conn
+
+	type userKey struct {
+		userID      int64
+		wrapped     []byte
+		masterKeyID string
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT user_id, wrapped_key, master_key_id FROM user_keys`)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	var userKeys []userKey
+	for rows.Next() {
+		var k userKey
+		if err := rows.Scan(&k.userID, &k.wrapped, &k.masterKeyID); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		userKeys = append(userKeys, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	for _, k := range userKeys {
+		wrapped, err := encryption.Rewrap(s.keys, next, k.wrapped, k.masterKeyID)
+		if err != nil {
+			return result, fmt.Errorf("%s: user %d: %w", op, k.userID, err)
+		}
+		if wrapped == nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE user_keys SET wrapped_key = ?, master_key_id = ? WHERE user_id = ?`, wrapped, next.ID(), k.userID)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		result.Rewrapped++
+	}
+
+	// Seal what is still plain text or sealed in an older format, trashed
+	// workouts included, with the rewrapped keys.
+	sealed, err := rekeyed.resealWorkouts(ctx, tx)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Sealed += sealed
+	if sealed, err = rekeyed.resealTemplates(ctx, tx); err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Sealed += sealed
+	if sealed, err = rekeyed.resealRecurringWorkouts(ctx, tx); err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Sealed += sealed
+	if sealed, err = rekeyed.resealBodyWeights(ctx, tx); err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	result.Sealed += sealed
+
+	if err := sqlTx.Commit(); err != nil {
+		return result, fmt.Errorf("%s: failed to commit transaction: %w", op, s.mapError(err))
+	}
+	return result, nil
+}
+
+// resealWorkouts seals the outdated fields of every workout and returns how
+// many workouts it changed.
+func (s *Storage) resealWorkouts(ctx context.Context, tx queryer) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT workout_id, user_id, notes, photo FROM workouts
+			 WHERE (notes IS NOT NULL AND notes != '') OR (photo IS NOT NULL AND photo != '')`)
+	if err != nil {
+		return 0, s.mapError(err)
+	}
+	var outdated []storage.WorkoutInfo
+	for rows.Next() {
+		var w storage.WorkoutInfo
+		var notes, photo sql.NullString
+		if err := rows.Scan(&w.WorkoutID, &w.UserID, &notes, &photo); err != nil {
+			rows.Close()
+			return 0, s.mapError(err)
+		}
+		w.Notes, w.Photo = notes.String, photo.String
+		if w.Outdated() {
+			outdated = append(outdated, w)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, s.mapError(err)
+	}
+
+	for _, w := range outdated {
+		key, err := s.dataKey(ctx, tx, w.UserID, true)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", w.UserID, err)
+		}
+		if err := w.Reseal(key); err != nil {
+			return 0, fmt.Errorf("workout %d: %w", w.WorkoutID, err)
+		}
+		// Sealing changes how the fields are stored, not the workout, so the
+		// version stays.
+		_, err = tx.ExecContext(ctx, `UPDATE workouts SET notes = ?, photo = ? WHERE workout_id = ?`, w.Notes, w.Photo, w.WorkoutID)
+		if err != nil {
+			return 0, s.mapError(err)
+		}
+	}
+	return len(outdated), nil
+}
+
+// resealTemplates seals the outdated notes of every template and returns how
+// many templates it changed.
+func (s *Storage) resealTemplates(ctx context.Context, tx queryer) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT template_id, user_id, notes FROM workout_templates WHERE notes != ''`)
+	if err != nil {
+		return 0, s.mapError(err)
+	}
+	var outdated []storage.WorkoutTemplate
+	for rows.Next() {
+		var t storage.WorkoutTemplate
+		if err := rows.Scan(&t.TemplateID, &t.UserID, &t.Notes); err != nil {
+			rows.Close()
+			return 0, s.mapError(err)
+		}
+		if !encryption.IsCurrent(t.Notes) {
+			outdated = append(outdated, t)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, s.mapError(err)
+	}
+
+	for _, t := range outdated {
+		key, err := s.dataKey(ctx, tx, t.UserID, true)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", t.UserID, err)
+		}
+		if err := t.Reseal(key); err != nil {
+			return 0, fmt.Errorf("template %d: %w", t.TemplateID, err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE workout_templates SET notes = ? WHERE template_id = ?`, t.Notes, t.TemplateID)
+		if err != nil {
+			return 0, s.mapError(err)
+		}
+	}
+	return len(outdated), nil
+}
+
+// resealRecurringWorkouts seals the outdated notes of every recurring workout
+// and returns how many it changed.
+func (s *Storage) resealRecurringWorkouts(ctx context.Context, tx queryer) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT recurring_workout_id, user_id, notes FROM recurring_workouts WHERE notes != ''`)
+	if err != nil {
+		return 0, s.mapError(err)
+	}
+	var outdated []storage.RecurringWorkout
+	for rows.Next() {
+		var w storage.RecurringWorkout
+		if err := rows.Scan(&w.RecurringWorkoutID, &w.UserID, &w.Notes); err != nil {
+			rows.Close()
+			return 0, s.mapError(err)
+		}
+		if !encryption.IsCurrent(w.Notes) {
+			outdated = append(outdated, w)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, s.mapError(err)
+	}
+
+	for _, w := range outdated {
+		key, err := s.dataKey(ctx, tx, w.UserID, true)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", w.UserID, err)
+		}
+		if err := w.Reseal(key); err != nil {
+			return 0, fmt.Errorf("recurring workout %d: %w", w.RecurringWorkoutID, err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE recurring_workouts SET notes = ? WHERE recurring_workout_id = ?`, w.Notes, w.RecurringWorkoutID)
+		if err != nil {
+			return 0, s.mapError(err)
+		}
+	}
+	return len(outdated), nil
+}
+
+// resealBodyWeights seals every outdated body weight and returns how many it
+// changed.
+func (s *Storage) resealBodyWeights(ctx context.Context, tx queryer) (int, error) {
+	type entry struct {
+		bodyWeightID, userID int64
+		weight               string
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT body_weight_id, user_id, weight FROM body_weights`)
+	if err != nil {
+		return 0, s.mapError(err)
+	}
+	var outdated []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.bodyWeightID, &e.userID, &e.weight); err != nil {
+			rows.Close()
+			return 0, s.mapError(err)
+		}
+		if !encryption.IsCurrent(e.weight) {
+			outdated = append(outdated, e)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, s.mapError(err)
+	}
+
+	for _, e := range outdated {
+		key, err := s.dataKey(ctx, tx, e.userID, true)
+		if err != nil {
+			return 0, fmt.Errorf("user %d: %w", e.userID, err)
+		}
+		sealed, err := storage.ResealBodyWeight(key, storage.BodyWeightField(e.userID, e.bodyWeightID), e.weight)
+		if err != nil {
+			return 0, fmt.Errorf("body weight %d: %w", e.bodyWeightID, err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE body_weights SET weight = ? WHERE body_weight_id = ?`, sealed, e.bodyWeightID)
+		if err != nil {
+			return 0, s.mapError(err)
+		}
+	}
+	return len(outdated), nil
+}
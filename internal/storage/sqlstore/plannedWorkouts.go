@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+// completePlannedWorkout links a new workout, the first argument, to the
+// earliest open plan of its user and date. Plans whose workout went to the
+// trash are open again.
+const completePlannedWorkout = `UPDATE planned_workouts SET workout_id = ?
+	WHERE planned_workout_id = (
+		SELECT pw.planned_workout_id FROM planned_workouts pw
+		LEFT JOIN workouts w ON w.workout_id = pw.workout_id AND w.deleted_at IS NULL
+		WHERE pw.user_id = ? AND pw.planned_date = ? AND w.workout_id IS NULL
+		ORDER BY pw.planned_workout_id
+		LIMIT 1)`
+
+const selectPlannedWorkouts = `SELECT pw.planned_workout_id, pw.enrollment_id, p.name, pw.week, pw.day, pw.planned_date,
+			 pw.template_id, wt.name, w.workout_id
+			 FROM planned_workouts pw
+			 JOIN program_enrollments pe ON pe.enrollment_id = pw.enrollment_id
+			 JOIN programs p ON p.program_id = pe.program_id
+			 JOIN workout_templates wt ON wt.template_id = pw.template_id
+			 LEFT JOIN workouts w ON w.workout_id = pw.workout_id AND w.deleted_at IS NULL`
+
+func (s *Storage) GetPlannedWorkouts(ctx context.Context, userID int64, from, to string) ([]storage.PlannedWorkout, error) {
+	const op = "storage.sqlstore.GetPlannedWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := selectPlannedWorkouts + `
+			 WHERE pw.user_id = ? AND pw.planned_date >= ? AND pw.planned_date <= ?
+			 ORDER BY pw.planned_date, pw.planned_workout_id`
+	rows, err := s.conn.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	plans, err := scanPlannedWorkouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return plans, nil
+}
+
+func (s *Storage) GetPlannedWorkout(ctx context.Context, userID, plannedWorkoutID int64) (*storage.PlannedWorkout, error) {
+	const op = "storage.sqlstore.GetPlannedWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := selectPlannedWorkouts + `
+			 WHERE pw.user_id = ? AND pw.planned_workout_id = ?`
+	rows, err := s.conn.QueryContext(ctx, query, userID, plannedWorkoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	plans, err := scanPlannedWorkouts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("%s: planned workout %w", op, storage.ErrNotFound)
+	}
+
+	return &plans[0], nil
+}
+
+func scanPlannedWorkouts(rows *sql.Rows) ([]storage.PlannedWorkout, error) {
+	plans := []storage.PlannedWorkout{}
+	for rows.Next() {
+		var plan storage.PlannedWorkout
+		err := rows.Scan(&plan.PlannedWorkoutID, &plan.EnrollmentID, &plan.ProgramName, &plan.Week, &plan.Day, &plan.Date,
+			&plan.TemplateID, &plan.TemplateName, &plan.WorkoutID)
+		if err != nil {
+			return nil, err
+		}
+		plan.Completed = plan.WorkoutID != nil
+		plans = append(plans, plan)
+	}
+	return plans, rows.Err()
+}
+
+func (s *Storage) LinkPlannedWorkout(ctx context.Context, plannedWorkoutID, workoutID int64) error {
+	const op = "storage.sqlstore.LinkPlannedWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE planned_workouts SET workout_id = NULL WHERE workout_id = ? AND planned_workout_id <> ?`
+	if _, err := tx.ExecContext(ctx, query, workoutID, plannedWorkoutID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	query = `UPDATE planned_workouts SET workout_id = ? WHERE planned_workout_id = ?`
+	result, err := tx.ExecContext(ctx, query, workoutID, plannedWorkoutID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: planned workout %w", op, storage.ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
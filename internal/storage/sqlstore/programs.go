@@ -0,0 +1,417 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddProgram(ctx context.Context, program storage.Program) (int64, error) {
+	const op = "storage.sqlstore.AddProgram"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if program.Name == "" {
+		return 0, fmt.Errorf("%s: name is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO programs (user_id, name, description, weeks) VALUES (?, ?, ?, ?)
+			 RETURNING program_id`
+	var programID int64
+	err = tx.QueryRowContext(ctx, query, program.UserID, program.Name, program.Description, len(program.Weeks)).Scan(&programID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if err := s.addProgramDays(ctx, tx, programID, program.Weeks); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return programID, nil
+}
+
+func (s *Storage) addProgramDays(ctx context.Context, tx queryer, programID int64, weeks []storage.ProgramWeek) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO program_days (program_id, week, day, template_id) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, week := range weeks {
+		for _, day := range week.Days {
+			if _, err := stmt.ExecContext(ctx, programID, i+1, day.Day, day.TemplateID); err != nil {
+				return fmt.Errorf("failed to add day: %w", s.mapError(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetPrograms(ctx context.Context, userID int64) ([]storage.Program, error) {
+	const op = "storage.sqlstore.GetPrograms"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	programs, err := s.programs(ctx, userID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return programs, nil
+}
+
+func (s *Storage) GetProgram(ctx context.Context, userID, programID int64) (*storage.Program, error) {
+	const op = "storage.sqlstore.GetProgram"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	programs, err := s.programs(ctx, userID, programID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(programs) == 0 {
+		return nil, fmt.Errorf("%s: program %w", op, storage.ErrNotFound)
+	}
+
+	return &programs[0], nil
+}
+
+// programs reads the programs of userID by name, or only programID unless it
+// is zero, with their days.
+func (s *Storage) programs(ctx context.Context, userID, programID int64) ([]storage.Program, error) {
+	query := `SELECT program_id, user_id, name, description, weeks FROM programs
+			 WHERE user_id = ? AND (CAST(? AS BIGINT) = 0 OR program_id = ?)
+			 ORDER BY name`
+	rows, err := s.conn.QueryContext(ctx, query, userID, programID, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []storage.Program{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var (
+			program storage.Program
+			weeks   int
+		)
+		if err := rows.Scan(&program.ProgramID, &program.UserID, &program.Name, &program.Description, &weeks); err != nil {
+			return nil, err
+		}
+		program.Weeks = make([]storage.ProgramWeek, weeks)
+		for i := range program.Weeks {
+			program.Weeks[i].Days = []storage.ProgramDay{}
+		}
+		index[program.ProgramID] = len(programs)
+		programs = append(programs, program)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(programs) == 0 {
+		return programs, nil
+	}
+
+	query = `SELECT pd.program_id, pd.week, pd.day, pd.template_id, wt.name
+			 FROM program_days pd
+			 JOIN programs p ON p.program_id = pd.program_id
+			 JOIN workout_templates wt ON wt.template_id = pd.template_id
+			 WHERE p.user_id = ? AND (CAST(? AS BIGINT) = 0 OR p.program_id = ?)
+			 ORDER BY pd.program_id, pd.week, pd.day`
+	rows, err = s.conn.QueryContext(ctx, query, userID, programID, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			programID int64
+			week      int
+			day       storage.ProgramDay
+		)
+		if err := rows.Scan(&programID, &week, &day.Day, &day.TemplateID, &day.TemplateName); err != nil {
+			return nil, err
+		}
+		program := &programs[index[programID]]
+		if week <= len(program.Weeks) {
+			program.Weeks[week-1].Days = append(program.Weeks[week-1].Days, day)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return programs, nil
+}
+
+func (s *Storage) UpdateProgram(ctx context.Context, userID, programID int64, program storage.Program) error {
+	const op = "storage.sqlstore.UpdateProgram"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if program.Name == "" {
+		return fmt.Errorf("%s: name is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE programs SET name = ?, description = ?, weeks = ? WHERE program_id = ? AND user_id = ?`
+	result, err := tx.ExecContext(ctx, query, program.Name, program.Description, len(program.Weeks), programID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: program %w", op, storage.ErrNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM program_days WHERE program_id = ?`, programID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if err := s.addProgramDays(ctx, tx, programID, program.Weeks); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteProgram(ctx context.Context, userID, programID int64) error {
+	const op = "storage.sqlstore.DeleteProgram"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM programs WHERE program_id = ? AND user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, programID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: program %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) AddEnrollment(ctx context.Context, enrollment storage.Enrollment) (int64, error) {
+	const op = "storage.sqlstore.AddEnrollment"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	start, err := time.Parse(time.DateOnly, enrollment.StartDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid start date: %w", op, err)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	type programDay struct {
+		week, day  int
+		templateID int64
+	}
+	var days []programDay
+	query := `SELECT pd.week, pd.day, pd.template_id FROM programs p
+			 LEFT JOIN program_days pd ON pd.program_id = p.program_id
+			 WHERE p.program_id = ? AND p.user_id = ?
+			 ORDER BY pd.week, pd.day`
+	rows, err := tx.QueryContext(ctx, query, enrollment.ProgramID, enrollment.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	found := false
+	for rows.Next() {
+		var (
+			week, day  sql.NullInt64
+			templateID sql.NullInt64
+		)
+		if err := rows.Scan(&week, &day, &templateID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		found = true
+		// A program without days comes back as one row of NULLs.
+		if week.Valid {
+			days = append(days, programDay{int(week.Int64), int(day.Int64), templateID.Int64})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if !found {
+		return 0, fmt.Errorf("%s: program %w", op, storage.ErrNotFound)
+	}
+
+	query = `INSERT INTO program_enrollments (user_id, program_id, start_date) VALUES (?, ?, ?)
+			 RETURNING enrollment_id`
+	var enrollmentID int64
+	err = tx.QueryRowContext(ctx, query, enrollment.UserID, enrollment.ProgramID, enrollment.StartDate).Scan(&enrollmentID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	query = `INSERT INTO training_maxes (enrollment_id, exercise_id, weight) VALUES (?, ?, ?)`
+	for _, tm := range enrollment.TrainingMaxes {
+		if _, err := tx.ExecContext(ctx, query, enrollmentID, tm.ExerciseID, tm.Weight); err != nil {
+			return 0, fmt.Errorf("%s: failed to add training max: %w", op, s.mapError(err))
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO planned_workouts (user_id, enrollment_id, week, day, template_id, planned_date)
+			 VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	for _, d := range days {
+		date := start.AddDate(0, 0, (d.week-1)*7+d.day-1).Format(time.DateOnly)
+		if _, err := stmt.ExecContext(ctx, enrollment.UserID, enrollmentID, d.week, d.day, d.templateID, date); err != nil {
+			return 0, fmt.Errorf("%s: failed to plan workout: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return enrollmentID, nil
+}
+
+func (s *Storage) GetEnrollments(ctx context.Context, userID int64) ([]storage.Enrollment, error) {
+	const op = "storage.sqlstore.GetEnrollments"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	enrollments, err := s.enrollments(ctx, userID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return enrollments, nil
+}
+
+func (s *Storage) GetEnrollment(ctx context.Context, userID, enrollmentID int64) (*storage.Enrollment, error) {
+	const op = "storage.sqlstore.GetEnrollment"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	enrollments, err := s.enrollments(ctx, userID, enrollmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(enrollments) == 0 {
+		return nil, fmt.Errorf("%s: enrollment %w", op, storage.ErrNotFound)
+	}
+
+	return &enrollments[0], nil
+}
+
+// enrollments reads the enrollments of userID by start date, or only
+// enrollmentID unless it is zero, with their training maxes.
+func (s *Storage) enrollments(ctx context.Context, userID, enrollmentID int64) ([]storage.Enrollment, error) {
+	query := `SELECT pe.enrollment_id, pe.user_id, pe.program_id, p.name, pe.start_date
+			 FROM program_enrollments pe
+			 JOIN programs p ON p.program_id = pe.program_id
+			 WHERE pe.user_id = ? AND (CAST(? AS BIGINT) = 0 OR pe.enrollment_id = ?)
+			 ORDER BY pe.start_date, pe.enrollment_id`
+	rows, err := s.conn.QueryContext(ctx, query, userID, enrollmentID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []storage.Enrollment{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		enrollment := storage.Enrollment{TrainingMaxes: []storage.TrainingMax{}}
+		if err := rows.Scan(&enrollment.EnrollmentID, &enrollment.UserID, &enrollment.ProgramID, &enrollment.ProgramName, &enrollment.StartDate); err != nil {
+			return nil, err
+		}
+		index[enrollment.EnrollmentID] = len(enrollments)
+		enrollments = append(enrollments, enrollment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(enrollments) == 0 {
+		return enrollments, nil
+	}
+
+	query = `SELECT tm.enrollment_id, tm.exercise_id, tm.weight
+			 FROM training_maxes tm
+			 JOIN program_enrollments pe ON pe.enrollment_id = tm.enrollment_id
+			 WHERE pe.user_id = ? AND (CAST(? AS BIGINT) = 0 OR pe.enrollment_id = ?)
+			 ORDER BY tm.enrollment_id, tm.exercise_id`
+	rows, err = s.conn.QueryContext(ctx, query, userID, enrollmentID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			enrollmentID int64
+			tm           storage.TrainingMax
+		)
+		if err := rows.Scan(&enrollmentID, &tm.ExerciseID, &tm.Weight); err != nil {
+			return nil, err
+		}
+		enrollment := &enrollments[index[enrollmentID]]
+		enrollment.TrainingMaxes = append(enrollment.TrainingMaxes, tm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return enrollments, nil
+}
+
+// DeleteEnrollment ends an enrollment and drops its planned workouts. The
+// workouts logged for them stay.
+func (s *Storage) DeleteEnrollment(ctx context.Context, userID, enrollmentID int64) error {
+	const op = "storage.sqlstore.DeleteEnrollment"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM program_enrollments WHERE enrollment_id = ? AND user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, enrollmentID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: enrollment %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
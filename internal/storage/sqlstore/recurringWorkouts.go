@@ -0,0 +1,309 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+// allFrom and allTo bound every date, to read all exceptions of a series.
+const (
+	allFrom = "0001-01-01"
+	allTo   = "9999-12-31"
+)
+
+func (s *Storage) AddRecurringWorkout(ctx context.Context, workout storage.RecurringWorkout) (int64, error) {
+	const op = "storage.sqlstore.AddRecurringWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if workout.Title == "" || workout.RRule == "" {
+		return 0, fmt.Errorf("%s: title and rule are required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Sealed notes are bound to the series' ID, so they are stored once it is
+	// known.
+	query := `INSERT INTO recurring_workouts (user_id, title, notes, start_date, start_time, rrule, template_id)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)
+			 RETURNING recurring_workout_id`
+	notes, sealed := workout.Notes, s.keys != nil && workout.Notes != ""
+	if sealed {
+		notes = ""
+	}
+	var recurringWorkoutID int64
+	err = tx.QueryRowContext(ctx, query, workout.UserID, workout.Title, notes, workout.StartDate, workout.Time, workout.RRule, workout.TemplateID).Scan(&recurringWorkoutID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if sealed {
+		workout.RecurringWorkoutID = recurringWorkoutID
+		if err := s.sealRecurringWorkout(ctx, tx, &workout); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		query = `UPDATE recurring_workouts SET notes = ? WHERE recurring_workout_id = ?`
+		if _, err := tx.ExecContext(ctx, query, workout.Notes, recurringWorkoutID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return recurringWorkoutID, nil
+}
+
+func (s *Storage) GetRecurringWorkouts(ctx context.Context, userID int64, from, to string) ([]storage.RecurringWorkout, error) {
+	const op = "storage.sqlstore.GetRecurringWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	workouts, err := s.recurringWorkouts(ctx, userID, 0, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return workouts, nil
+}
+
+func (s *Storage) GetRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64) (*storage.RecurringWorkout, error) {
+	const op = "storage.sqlstore.GetRecurringWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	workouts, err := s.recurringWorkouts(ctx, userID, recurringWorkoutID, allFrom, allTo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(workouts) == 0 {
+		return nil, fmt.Errorf("%s: recurring workout %w", op, storage.ErrNotFound)
+	}
+
+	return &workouts[0], nil
+}
+
+// recurringWorkouts reads the series of userID starting by to, or only
+// recurringWorkoutID unless it is zero, with the exceptions of occurrences
+// dated or moved between from and to.
+func (s *Storage) recurringWorkouts(ctx context.Context, userID, recurringWorkoutID int64, from, to string) ([]storage.RecurringWorkout, error) {
+	query := `SELECT recurring_workout_id, user_id, title, notes, start_date, start_time, rrule, template_id
+			 FROM recurring_workouts
+			 WHERE user_id = ? AND (CAST(? AS BIGINT) = 0 OR recurring_workout_id = ?) AND start_date <= ?
+			 ORDER BY start_date, recurring_workout_id`
+	rows, err := s.conn.QueryContext(ctx, query, userID, recurringWorkoutID, recurringWorkoutID, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []storage.RecurringWorkout{}
+	for rows.Next() {
+		workout := storage.RecurringWorkout{Exceptions: []storage.RecurrenceException{}}
+		err := rows.Scan(&workout.RecurringWorkoutID, &workout.UserID, &workout.Title, &workout.Notes,
+			&workout.StartDate, &workout.Time, &workout.RRule, &workout.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(workouts) == 0 {
+		return workouts, nil
+	}
+	for i := range workouts {
+		if err := s.openRecurringWorkout(ctx, s.conn, &workouts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `SELECT re.recurring_workout_id, re.occurrence_date, re.action, re.moved_date, re.moved_time, w.workout_id
+			 FROM recurrence_exceptions re
+			 JOIN recurring_workouts rw ON rw.recurring_workout_id = re.recurring_workout_id
+			 LEFT JOIN workouts w ON w.workout_id = re.workout_id AND w.deleted_at IS NULL
+			 WHERE rw.user_id = ? AND (CAST(? AS BIGINT) = 0 OR rw.recurring_workout_id = ?)
+			 AND (re.occurrence_date BETWEEN ? AND ? OR re.moved_date BETWEEN ? AND ?)
+			 ORDER BY re.recurring_workout_id, re.occurrence_date`
+	rows, err = s.conn.QueryContext(ctx, query, userID, recurringWorkoutID, recurringWorkoutID, from, to, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exceptions []storage.SeriesException
+	for rows.Next() {
+		var (
+			exception                    storage.SeriesException
+			action, movedDate, movedTime sql.NullString
+		)
+		err := rows.Scan(&exception.RecurringWorkoutID, &exception.Date, &action, &movedDate, &movedTime, &exception.WorkoutID)
+		if err != nil {
+			return nil, err
+		}
+		exception.Action = storage.RecurrenceAction(action.String)
+		exception.MovedDate, exception.MovedTime = movedDate.String, movedTime.String
+		exceptions = append(exceptions, exception)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	storage.AttachExceptions(workouts, exceptions)
+
+	return workouts, nil
+}
+
+func (s *Storage) UpdateRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64, workout storage.RecurringWorkout) error {
+	const op = "storage.sqlstore.UpdateRecurringWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if workout.Title == "" || workout.RRule == "" {
+		return fmt.Errorf("%s: title and rule are required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	workout.RecurringWorkoutID, workout.UserID = recurringWorkoutID, userID
+	if err := s.sealRecurringWorkout(ctx, tx, &workout); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	query := `UPDATE recurring_workouts SET title = ?, notes = ?, start_date = ?, start_time = ?, rrule = ?, template_id = ?
+			 WHERE recurring_workout_id = ? AND user_id = ?`
+	result, err := tx.ExecContext(ctx, query, workout.Title, workout.Notes, workout.StartDate, workout.Time, workout.RRule, workout.TemplateID,
+		recurringWorkoutID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: recurring workout %w", op, storage.ErrNotFound)
+	}
+	query = `DELETE FROM recurrence_exceptions WHERE recurring_workout_id = ? AND workout_id IS NULL`
+	if _, err := tx.ExecContext(ctx, query, recurringWorkoutID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	query = `UPDATE recurrence_exceptions SET action = NULL, moved_date = NULL, moved_time = NULL WHERE recurring_workout_id = ?`
+	if _, err := tx.ExecContext(ctx, query, recurringWorkoutID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64) error {
+	const op = "storage.sqlstore.DeleteRecurringWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM recurring_workouts WHERE recurring_workout_id = ? AND user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, recurringWorkoutID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: recurring workout %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) SetRecurrenceException(ctx context.Context, userID, recurringWorkoutID int64, exception storage.RecurrenceException) error {
+	const op = "storage.sqlstore.SetRecurrenceException"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `INSERT INTO recurrence_exceptions (recurring_workout_id, occurrence_date, action, moved_date, moved_time)
+			 SELECT recurring_workout_id, ?, ?, ?, ? FROM recurring_workouts
+			 WHERE recurring_workout_id = ? AND user_id = ?
+			 ON CONFLICT (recurring_workout_id, occurrence_date) DO UPDATE
+			 SET action = excluded.action, moved_date = excluded.moved_date, moved_time = excluded.moved_time`
+
+	result, err := s.conn.ExecContext(ctx, query, exception.Date, exception.Action,
+		sql.NullString{String: exception.MovedDate, Valid: exception.MovedDate != ""},
+		sql.NullString{String: exception.MovedTime, Valid: exception.Action == storage.RecurrenceMove},
+		recurringWorkoutID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: recurring workout %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteRecurrenceException(ctx context.Context, userID, recurringWorkoutID int64, date string) error {
+	const op = "storage.sqlstore.DeleteRecurrenceException"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE recurrence_exceptions SET action = NULL, moved_date = NULL, moved_time = NULL
+			 WHERE recurring_workout_id = ? AND occurrence_date = ? AND action IS NOT NULL
+			 AND recurring_workout_id IN (SELECT recurring_workout_id FROM recurring_workouts WHERE user_id = ?)`
+	result, err := tx.ExecContext(ctx, query, recurringWorkoutID, date, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: exception %w", op, storage.ErrNotFound)
+	}
+	query = `DELETE FROM recurrence_exceptions WHERE recurring_workout_id = ? AND occurrence_date = ? AND workout_id IS NULL`
+	if _, err := tx.ExecContext(ctx, query, recurringWorkoutID, date); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) LinkRecurrenceWorkout(ctx context.Context, recurringWorkoutID int64, date string, workoutID int64) error {
+	const op = "storage.sqlstore.LinkRecurrenceWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `INSERT INTO recurrence_exceptions (recurring_workout_id, occurrence_date, workout_id) VALUES (?, ?, ?)
+			 ON CONFLICT (recurring_workout_id, occurrence_date) DO UPDATE SET workout_id = excluded.workout_id`
+
+	if _, err := s.conn.ExecContext(ctx, query, recurringWorkoutID, date, workoutID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+// SearchWorkouts runs a full-text search over the user's workout notes and
+// exercise names, best matches first. While notes are encrypted they cannot
+// be indexed, so only exercise names are searched. Where the database cannot
+// search it returns storage.ErrSearchUnavailable.
+func (s *Storage) SearchWorkouts(ctx context.Context, userID int64, filter storage.SearchFilter) ([]storage.SearchResult, error) {
+	const op = "storage.sqlstore.SearchWorkouts"
+	terms := storage.SearchTerms(filter.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query, args, err := s.dialect.SearchQuery(userID, terms, s.keys != nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if filter.From != "" {
+		query += ` AND w.workout_date >= ?`
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += ` AND w.workout_date <= ?`
+		args = append(args, filter.To)
+	}
+	if filter.ExerciseID != 0 {
+		query += ` AND EXISTS (SELECT 1 FROM workout_exercises we WHERE we.workout_id = w.workout_id AND we.exercise_id = ?)`
+		args = append(args, filter.ExerciseID)
+	}
+	query += ` ORDER BY 11 DESC, w.workout_date DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var results []storage.SearchResult
+	for rows.Next() {
+		var result storage.SearchResult
+		err := rows.Scan(
+			&result.WorkoutID,
+			&result.UserID,
+			&result.Date,
+			&result.StartTime,
+			&result.EndTime,
+			&result.Notes,
+			&result.Photo,
+			&result.Version,
+			&result.NotesSnippet,
+			&result.ExercisesSnippet,
+			&result.Rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range results {
+		if err := s.openWorkout(ctx, s.conn, &results[i].WorkoutInfo); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return results, nil
+}
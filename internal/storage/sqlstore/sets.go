@@ -0,0 +1,268 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddSet(ctx context.Context, set storage.Set) error {
+	const op = "storage.sqlstore.AddSet"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	if set.WorkoutExerciseID == 0 {
+		return fmt.Errorf("%s: workout exercise ID is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO sets (workout_exercise_id, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, query, set.WorkoutExerciseID, set.Repetitions, set.Weight, set.Type, set.RPE, set.RIR, set.RestSeconds, set.Tempo, set.DurationSeconds, set.DistanceMeters); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if _, err := tx.ExecContext(ctx, bumpVersionByExercise, set.WorkoutExerciseID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AddSets(ctx context.Context, sets []storage.Set) error {
+	const op = "storage.sqlstore.AddSets"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO sets (workout_exercise_id, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	bump, err := tx.PrepareContext(ctx, bumpVersionByExercise)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer bump.Close()
+
+	for _, set := range sets {
+		if set.WorkoutExerciseID == 0 {
+			return fmt.Errorf("%s: workout exercise ID is required", op)
+		}
+		_, err := stmt.ExecContext(ctx, set.WorkoutExerciseID, set.Repetitions, set.Weight, set.Type, set.RPE, set.RIR, set.RestSeconds, set.Tempo, set.DurationSeconds, set.DistanceMeters)
+		if err != nil {
+			return fmt.Errorf("%s: failed to add set: %w", op, s.mapError(err))
+		}
+		if _, err := bump.ExecContext(ctx, set.WorkoutExerciseID); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+func (s *Storage) DeleteSet(ctx context.Context, setID int64) error {
+	const op = "storage.sqlstore.DeleteSet"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, bumpVersionBySet, setID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	query := `DELETE FROM sets WHERE set_id = ?`
+	if _, err := tx.ExecContext(ctx, query, setID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteSets(ctx context.Context, setIDs []int64) error {
+	const op = "storage.sqlstore.DeleteSets"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM sets WHERE set_id = ?`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	bump, err := tx.PrepareContext(ctx, bumpVersionBySet)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer bump.Close()
+
+	for _, setID := range setIDs {
+		if _, err := bump.ExecContext(ctx, setID); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		_, err := stmt.ExecContext(ctx, setID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete set with ID %d: %w", op, setID, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+func (s *Storage) GetSet(ctx context.Context, setID int64) (*storage.SetInfo, error) {
+	const op = "storage.sqlstore.GetSet"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT set_id, workout_exercise_id, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters
+			 FROM sets WHERE set_id = ?`
+
+	set := &storage.SetInfo{}
+	err := s.conn.QueryRowContext(ctx, query, setID).Scan(
+		&set.SetID,
+		&set.WorkoutExerciseID,
+		&set.Repetitions,
+		&set.Weight,
+		&set.Type,
+		&set.RPE,
+		&set.RIR,
+		&set.RestSeconds,
+		&set.Tempo,
+		&set.DurationSeconds,
+		&set.DistanceMeters,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s: set %w", op, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	set.Derive()
+
+	return set, nil
+}
+
+func (s *Storage) GetSets(ctx context.Context, workoutExerciseID int64) ([]storage.SetInfo, error) {
+	const op = "storage.sqlstore.GetSets"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT set_id, workout_exercise_id, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters
+			 FROM sets WHERE workout_exercise_id = ?
+			 ORDER BY set_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, workoutExerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var sets []storage.SetInfo
+	for rows.Next() {
+		var set storage.SetInfo
+		err := rows.Scan(
+			&set.SetID,
+			&set.WorkoutExerciseID,
+			&set.Repetitions,
+			&set.Weight,
+			&set.Type,
+			&set.RPE,
+			&set.RIR,
+			&set.RestSeconds,
+			&set.Tempo,
+			&set.DurationSeconds,
+			&set.DistanceMeters,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		set.Derive()
+		sets = append(sets, set)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return sets, nil
+}
+
+// ReplaceSets swaps the sets of a workout exercise for sets if the exercise
+// belongs to workoutID and the workout is still at version.
+func (s *Storage) ReplaceSets(ctx context.Context, workoutID, version, workoutExerciseID int64, sets []storage.SetInfo) error {
+	const op = "storage.sqlstore.ReplaceSets"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := s.bumpVersionIfOwnsExercise(ctx, tx, workoutID, version, workoutExerciseID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	queryDelete := `DELETE FROM sets WHERE workout_exercise_id = ? AND set_id IS NOT NULL`
+	_, err = tx.ExecContext(ctx, queryDelete, workoutExerciseID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to delete existing sets: %w", op, s.mapError(err))
+	}
+
+	queryInsert := `INSERT INTO sets (workout_exercise_id, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.PrepareContext(ctx, queryInsert)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare insert statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, set := range sets {
+		_, err := stmt.ExecContext(ctx, workoutExerciseID, set.Repetitions, set.Weight, set.Type, set.RPE, set.RIR, set.RestSeconds, set.Tempo, set.DurationSeconds, set.DistanceMeters)
+		if err != nil {
+			return fmt.Errorf("%s: failed to insert set: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
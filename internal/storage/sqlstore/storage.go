@@ -0,0 +1,70 @@
+// Package sqlstore implements storage.Storage over database/sql for every
+// database the server runs on. Queries are written once, with ? placeholders
+// and SQL that SQLite and PostgreSQL both accept; a Dialect covers the rest.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"diaryserver/internal/encryption"
+	"diaryserver/internal/storage"
+	"time"
+)
+
+var _ storage.Storage = (*Storage)(nil)
+
+// Dialect is what sets one database apart from the others.
+type Dialect interface {
+	// Rebind rewrites the ? placeholders of query into the database's own.
+	Rebind(query string) string
+	// MapError translates driver errors, and sql.ErrNoRows, into the storage
+	// package's errors.
+	MapError(err error) error
+	// SecondsAgo is an expression for the moment a placeholder's number of
+	// seconds before now, comparable with CURRENT_TIMESTAMP columns.
+	SecondsAgo() string
+	// SearchQuery returns the query that finds the workouts of a user whose
+	// notes or exercise names match every one of terms as a prefix, and its
+	// arguments. With exercisesOnly set, only exercise names match and the
+	// notes snippet is empty. It selects the columns of storage.SearchResult
+	// in order, the rank last; SearchWorkouts appends the filters and ORDER BY
+	// to it, with the workouts aliased as w. It returns
+	// storage.ErrSearchUnavailable when the database cannot search.
+	SearchQuery(userID int64, terms []string, exercisesOnly bool) (string, []any, error)
+}
+
+type Storage struct {
+	db      *sql.DB
+	dialect Dialect
+	// conn is what statements run on: db, or the transaction of the unit of
+	// work this Storage was handed to by WithTx, which is then also tx.
+	conn queryer
+	tx   *sql.Tx
+	// keys seals sensitive fields; it is nil when no master key is configured.
+	keys         *encryption.Keyring
+	queryTimeout time.Duration
+}
+
+// New returns a Storage running on db in dialect. A positive queryTimeout
+// bounds every storage call on top of the caller's context. With keys set,
+// sensitive fields are encrypted at rest.
+func New(db *sql.DB, dialect Dialect, queryTimeout time.Duration, keys *encryption.Keyring) *Storage {
+	return &Storage{db: db, dialect: dialect, conn: rebound{db, dialect}, keys: keys, queryTimeout: queryTimeout}
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// queryContext derives the context a single storage call runs under.
+func (s *Storage) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// mapError translates driver errors into the storage package's errors.
+func (s *Storage) mapError(err error) error {
+	return s.dialect.MapError(err)
+}
@@ -0,0 +1,266 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddTemplate(ctx context.Context, template storage.WorkoutTemplate) (int64, error) {
+	const op = "storage.sqlstore.AddTemplate"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if template.Name == "" {
+		return 0, fmt.Errorf("%s: name is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Sealed notes are bound to the template's ID, so they are stored once
+	// it is known.
+	query := `INSERT INTO workout_templates (user_id, name, notes) VALUES (?, ?, ?) RETURNING template_id`
+	notes, sealed := template.Notes, s.keys != nil && template.Notes != ""
+	if sealed {
+		notes = ""
+	}
+	var templateID int64
+	err = tx.QueryRowContext(ctx, query, template.UserID, template.Name, notes).Scan(&templateID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if sealed {
+		template.TemplateID = templateID
+		if err := s.sealTemplate(ctx, tx, &template); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE workout_templates SET notes = ? WHERE template_id = ?`, template.Notes, templateID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+	if err := s.addTemplateExercises(ctx, tx, templateID, template.Exercises); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return templateID, nil
+}
+
+// addTemplateExercises adds exercises and their sets to a template in the
+// order given.
+func (s *Storage) addTemplateExercises(ctx context.Context, tx queryer, templateID int64, exercises []storage.TemplateExercise) error {
+	exerciseStmt, err := tx.PrepareContext(ctx, `INSERT INTO template_exercises (template_id, exercise_id, position) VALUES (?, ?, ?)
+			 RETURNING template_exercise_id`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer exerciseStmt.Close()
+	setStmt, err := tx.PrepareContext(ctx, `INSERT INTO template_sets (template_exercise_id, position, repetitions, weight, set_type, rpe, rir, rest_seconds, tempo, duration_seconds, distance_meters, percent_of_tm)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer setStmt.Close()
+
+	for i, exercise := range exercises {
+		var templateExerciseID int64
+		err := exerciseStmt.QueryRowContext(ctx, templateID, exercise.ExerciseID, i+1).Scan(&templateExerciseID)
+		if err != nil {
+			return fmt.Errorf("failed to add exercise: %w", s.mapError(err))
+		}
+		for j, set := range exercise.Sets {
+			_, err := setStmt.ExecContext(ctx, templateExerciseID, j+1, set.Repetitions, set.Weight, set.Type, set.RPE, set.RIR, set.RestSeconds, set.Tempo, set.DurationSeconds, set.DistanceMeters, set.PercentOfTrainingMax)
+			if err != nil {
+				return fmt.Errorf("failed to add set: %w", s.mapError(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetTemplates(ctx context.Context, userID int64) ([]storage.WorkoutTemplate, error) {
+	const op = "storage.sqlstore.GetTemplates"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	templates, err := s.templates(ctx, userID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return templates, nil
+}
+
+func (s *Storage) GetTemplate(ctx context.Context, userID, templateID int64) (*storage.WorkoutTemplate, error) {
+	const op = "storage.sqlstore.GetTemplate"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	templates, err := s.templates(ctx, userID, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("%s: template %w", op, storage.ErrNotFound)
+	}
+
+	return &templates[0], nil
+}
+
+// templates reads the templates of userID by name, or only templateID unless
+// it is zero, with their exercises and sets.
+func (s *Storage) templates(ctx context.Context, userID, templateID int64) ([]storage.WorkoutTemplate, error) {
+	query := `SELECT template_id, user_id, name, notes FROM workout_templates
+			 WHERE user_id = ? AND (CAST(? AS BIGINT) = 0 OR template_id = ?)
+			 ORDER BY name`
+	rows, err := s.conn.QueryContext(ctx, query, userID, templateID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []storage.WorkoutTemplate{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		template := storage.WorkoutTemplate{Exercises: []storage.TemplateExercise{}}
+		if err := rows.Scan(&template.TemplateID, &template.UserID, &template.Name, &template.Notes); err != nil {
+			return nil, err
+		}
+		index[template.TemplateID] = len(templates)
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return templates, nil
+	}
+	for i := range templates {
+		if err := s.openTemplate(ctx, s.conn, &templates[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `SELECT te.template_id, te.template_exercise_id, te.exercise_id, ae.name,
+			 ts.repetitions, ts.weight, ts.set_type, ts.rpe, ts.rir, ts.rest_seconds, ts.tempo, ts.duration_seconds, ts.distance_meters, ts.percent_of_tm
+			 FROM template_exercises te
+			 JOIN workout_templates wt ON wt.template_id = te.template_id
+			 JOIN allowed_exercises ae ON ae.exercise_id = te.exercise_id
+			 LEFT JOIN template_sets ts ON ts.template_exercise_id = te.template_exercise_id
+			 WHERE wt.user_id = ? AND (CAST(? AS BIGINT) = 0 OR wt.template_id = ?)
+			 ORDER BY te.template_id, te.position, ts.position`
+	rows, err = s.conn.QueryContext(ctx, query, userID, templateID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastTemplateExerciseID int64
+	for rows.Next() {
+		var (
+			templateID, templateExerciseID int64
+			exercise                       storage.TemplateExercise
+			repetitions                    sql.NullInt64
+			weight                         sql.NullFloat64
+			set                            storage.TemplateSet
+			setType                        sql.NullString
+		)
+		err := rows.Scan(&templateID, &templateExerciseID, &exercise.ExerciseID, &exercise.ExerciseName,
+			&repetitions, &weight, &setType, &set.RPE, &set.RIR, &set.RestSeconds, &set.Tempo, &set.DurationSeconds, &set.DistanceMeters, &set.PercentOfTrainingMax)
+		if err != nil {
+			return nil, err
+		}
+		template := &templates[index[templateID]]
+		if templateExerciseID != lastTemplateExerciseID {
+			exercise.Sets = []storage.TemplateSet{}
+			template.Exercises = append(template.Exercises, exercise)
+			lastTemplateExerciseID = templateExerciseID
+		}
+		// An exercise without sets comes back as one row of NULLs.
+		if !setType.Valid {
+			continue
+		}
+		set.Repetitions, set.Weight, set.Type = int(repetitions.Int64), weight.Float64, storage.SetType(setType.String)
+		set.Derive()
+		last := &template.Exercises[len(template.Exercises)-1]
+		last.Sets = append(last.Sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (s *Storage) UpdateTemplate(ctx context.Context, userID, templateID int64, template storage.WorkoutTemplate) error {
+	const op = "storage.sqlstore.UpdateTemplate"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if template.Name == "" {
+		return fmt.Errorf("%s: name is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	template.TemplateID, template.UserID = templateID, userID
+	if err := s.sealTemplate(ctx, tx, &template); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	query := `UPDATE workout_templates SET name = ?, notes = ? WHERE template_id = ? AND user_id = ?`
+	result, err := tx.ExecContext(ctx, query, template.Name, template.Notes, templateID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: template %w", op, storage.ErrNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM template_exercises WHERE template_id = ?`, templateID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if err := s.addTemplateExercises(ctx, tx, templateID, template.Exercises); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteTemplate(ctx context.Context, userID, templateID int64) error {
+	const op = "storage.sqlstore.DeleteTemplate"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM workout_templates WHERE template_id = ? AND user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, templateID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: template %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) GetLocales(ctx context.Context) ([]string, error) {
+	const op = "storage.sqlstore.GetLocales"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT DISTINCT locale FROM exercise_translations ORDER BY locale`
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var locales []string
+	for rows.Next() {
+		var locale string
+		if err := rows.Scan(&locale); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		locales = append(locales, locale)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return locales, nil
+}
+
+func (s *Storage) GetExerciseTranslations(ctx context.Context, locale string) ([]storage.ExerciseTranslation, error) {
+	const op = "storage.sqlstore.GetExerciseTranslations"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT exercise_id, locale, name, description FROM exercise_translations
+			 WHERE locale = ? ORDER BY exercise_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, locale)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	translations, err := scanTranslations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return translations, nil
+}
+
+func (s *Storage) GetTranslationsOfExercise(ctx context.Context, exerciseID int64) ([]storage.ExerciseTranslation, error) {
+	const op = "storage.sqlstore.GetTranslationsOfExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT exercise_id, locale, name, description FROM exercise_translations
+			 WHERE exercise_id = ? ORDER BY locale`
+
+	rows, err := s.conn.QueryContext(ctx, query, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	translations, err := scanTranslations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return translations, nil
+}
+
+func scanTranslations(rows *sql.Rows) ([]storage.ExerciseTranslation, error) {
+	translations := []storage.ExerciseTranslation{}
+	for rows.Next() {
+		var t storage.ExerciseTranslation
+		if err := rows.Scan(&t.ExerciseID, &t.Locale, &t.Name, &t.Description); err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
+}
+
+func (s *Storage) PutExerciseTranslation(ctx context.Context, translation storage.ExerciseTranslation) error {
+	const op = "storage.sqlstore.PutExerciseTranslation"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if translation.Locale == "" || translation.Name == "" {
+		return fmt.Errorf("%s: locale and name are required", op)
+	}
+	query := `INSERT INTO exercise_translations (exercise_id, locale, name, description)
+			 SELECT exercise_id, CAST(? AS TEXT), CAST(? AS TEXT), CAST(? AS TEXT) FROM allowed_exercises WHERE exercise_id = ? AND owner_id IS NULL
+			 ON CONFLICT (exercise_id, locale) DO UPDATE SET name = excluded.name, description = excluded.description`
+
+	result, err := s.conn.ExecContext(ctx, query, translation.Locale, translation.Name, translation.Description, translation.ExerciseID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: exercise %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteExerciseTranslation(ctx context.Context, exerciseID int64, locale string) error {
+	const op = "storage.sqlstore.DeleteExerciseTranslation"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM exercise_translations WHERE exercise_id = ? AND locale = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, exerciseID, locale)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: translation %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"diaryserver/internal/storage"
+)
+
+// GetDeletedWorkouts returns the user's soft-deleted workouts, most recently
+// deleted first.
+func (s *Storage) GetDeletedWorkouts(ctx context.Context, userID int64) ([]storage.TrashedWorkout, error) {
+	const op = "storage.sqlstore.GetDeletedWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version, deleted_at
+			 FROM workouts WHERE user_id = ? AND deleted_at IS NOT NULL
+			 ORDER BY deleted_at DESC, workout_id DESC`
+
+	rows, err := s.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workouts []storage.TrashedWorkout
+	for rows.Next() {
+		var workout storage.TrashedWorkout
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+			&workout.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		workouts = append(workouts, workout)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range workouts {
+		if err := s.openWorkout(ctx, s.conn, &workouts[i].WorkoutInfo); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return workouts, nil
+}
+
+func (s *Storage) GetDeletedWorkout(ctx context.Context, workoutID int64) (*storage.TrashedWorkout, error) {
+	const op = "storage.sqlstore.GetDeletedWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version, deleted_at
+			 FROM workouts WHERE workout_id = ? AND deleted_at IS NOT NULL`
+
+	workout := &storage.TrashedWorkout{}
+	err := s.conn.QueryRowContext(ctx, query, workoutID).Scan(
+		&workout.WorkoutID,
+		&workout.UserID,
+		&workout.Date,
+		&workout.StartTime,
+		&workout.EndTime,
+		&workout.Notes,
+		&workout.Photo,
+		&workout.Version,
+		&workout.DeletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s: workout %w", op, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if err := s.openWorkout(ctx, s.conn, &workout.WorkoutInfo); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workout, nil
+}
+
+// RestoreWorkout takes a workout out of the trash.
+func (s *Storage) RestoreWorkout(ctx context.Context, workoutID int64) error {
+	const op = "storage.sqlstore.RestoreWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `UPDATE workouts SET deleted_at = NULL, version = version + 1 WHERE workout_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := s.conn.ExecContext(ctx, query, workoutID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: workout %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+// PurgeDeletedWorkouts hard-deletes workouts that have been in the trash for
+// longer than olderThan and returns them. Their exercises and sets go with
+// them through ON DELETE CASCADE.
+func (s *Storage) PurgeDeletedWorkouts(ctx context.Context, olderThan time.Duration) ([]storage.WorkoutInfo, error) {
+	const op = "storage.sqlstore.PurgeDeletedWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	cutoff := int64(olderThan.Seconds())
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version
+			 FROM workouts WHERE deleted_at IS NOT NULL AND deleted_at < ` + s.dialect.SecondsAgo()
+
+	rows, err := tx.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workouts []storage.WorkoutInfo
+	for rows.Next() {
+		var workout storage.WorkoutInfo
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		workouts = append(workouts, workout)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	rows.Close()
+	for i := range workouts {
+		if err := s.openWorkout(ctx, tx, &workouts[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM workouts WHERE workout_id = ?`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, workout := range workouts {
+		if _, err := stmt.ExecContext(ctx, workout.WorkoutID); err != nil {
+			return nil, fmt.Errorf("%s: failed to delete workout with ID %d: %w", op, workout.WorkoutID, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return workouts, nil
+}
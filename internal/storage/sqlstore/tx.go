@@ -0,0 +1,108 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"diaryserver/internal/storage"
+	"fmt"
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// rebound runs statements on q with their placeholders rewritten for dialect,
+// so that the queries of this package are written once.
+type rebound struct {
+	q       queryer
+	dialect Dialect
+}
+
+func (r rebound) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.q.ExecContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.q.QueryRowContext(ctx, r.dialect.Rebind(query), args...)
+}
+
+func (r rebound) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.q.PrepareContext(ctx, r.dialect.Rebind(query))
+}
+
+// txn is the transaction a single storage method writes in.
+type txn interface {
+	queryer
+	Commit() error
+	Rollback() error
+}
+
+// ownTx is a method's transaction when it runs on its own.
+type ownTx struct {
+	rebound
+	tx *sql.Tx
+}
+
+func (t ownTx) Commit() error   { return t.tx.Commit() }
+func (t ownTx) Rollback() error { return t.tx.Rollback() }
+
+// joinedTx is a method's transaction when it runs inside WithTx: the method
+// works in the enclosing transaction and leaves committing or rolling it back
+// to WithTx, which sees the method's error through fn.
+type joinedTx struct {
+	queryer
+}
+
+func (joinedTx) Commit() error   { return nil }
+func (joinedTx) Rollback() error { return nil }
+
+// begin starts the transaction for a single storage method.
+func (s *Storage) begin(ctx context.Context) (txn, error) {
+	if s.tx != nil {
+		return joinedTx{s.conn}, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ownTx{rebound{tx, s.dialect}, tx}, nil
+}
+
+// inTx returns a copy of s that runs its statements in tx.
+func (s *Storage) inTx(tx *sql.Tx) *Storage {
+	c := *s
+	c.conn, c.tx = rebound{tx, s.dialect}, tx
+	return &c
+}
+
+// WithTx runs fn in one transaction. Everything fn does through tx is
+// committed if fn returns nil and rolled back otherwise; fn's error is
+// returned as is. tx must not be used after fn returns.
+func (s *Storage) WithTx(ctx context.Context, fn func(tx storage.Repos) error) error {
+	const op = "storage.sqlstore.WithTx"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, s.mapError(err))
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.inTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, s.mapError(err))
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddUser(ctx context.Context, user storage.User) error {
+	const op = "storage.sqlstore.AddUser"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if user.Username == "" || user.Email == "" || user.PasswordHash == "" {
+		return fmt.Errorf("%s: username, email and password_hash are required", op)
+	}
+	query := `INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)`
+
+	_, err := s.conn.ExecContext(ctx, query, user.Username, user.Email, user.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) AddUsers(ctx context.Context, users []storage.User) error {
+	const op = "storage.sqlstore.AddUsers"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, user := range users {
+		if user.Username == "" || user.Email == "" || user.PasswordHash == "" {
+			return fmt.Errorf("%s: username, email and password_hash are required", op)
+		}
+		_, err := stmt.ExecContext(ctx, user.Username, user.Email, user.PasswordHash)
+		if err != nil {
+			return fmt.Errorf("%s: failed to add user %s: %w", op, user.Username, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteUser(ctx context.Context, username string) error {
+	const op = "storage.sqlstore.DeleteUser"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM users WHERE username = ?`
+	if _, err := s.conn.ExecContext(ctx, query, username); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	return nil
+}
+
+func (s *Storage) DeleteUsers(ctx context.Context, usernames []string) error {
+	const op = "storage.sqlstore.DeleteUsers"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+	query := `DELETE FROM users WHERE username = ?`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	for _, username := range usernames {
+		_, err := stmt.ExecContext(ctx, username)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete user %s: %w", op, username, s.mapError(err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+	return nil
+}
+func (s *Storage) DeleteAllUsers(ctx context.Context) error {
+	const op = "storage.sqlstore.DeleteAllUsers"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `DELETE FROM users`
+	if _, err := s.conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	return nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, username string) (*storage.UserInfo, error) {
+	const op = "storage.sqlstore.GetUser"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT user_id, username, email, password_hash, weight_unit FROM users WHERE username = ?`
+
+	row := s.conn.QueryRowContext(ctx, query, username)
+
+	var user storage.UserInfo
+	err := row.Scan(&user.UserID, &user.Username, &user.Email, &user.PasswordHash, &user.WeightUnit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return &user, nil
+}
+
+func (s *Storage) GetUsers(ctx context.Context) ([]storage.UserInfo, error) {
+	const op = "storage.sqlstore.GetUsers"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT user_id, username, email, password_hash, created_at, weight_unit FROM users`
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var users []storage.UserInfo
+	for rows.Next() {
+		var user storage.UserInfo
+		err := rows.Scan(&user.UserID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.WeightUnit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return users, nil
+}
+
+func (s *Storage) GetWeightUnit(ctx context.Context, userID int64) (storage.WeightUnit, error) {
+	const op = "storage.sqlstore.GetWeightUnit"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT weight_unit FROM users WHERE user_id = ?`
+
+	var unit storage.WeightUnit
+	if err := s.conn.QueryRowContext(ctx, query, userID).Scan(&unit); err != nil {
+		return "", fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return unit, nil
+}
+
+func (s *Storage) SetWeightUnit(ctx context.Context, userID int64, unit storage.WeightUnit) error {
+	const op = "storage.sqlstore.SetWeightUnit"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if !unit.Valid() {
+		return fmt.Errorf("%s: unknown weight unit %q", op, unit)
+	}
+	query := `UPDATE users SET weight_unit = ? WHERE user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, unit, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: user %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetLocale(ctx context.Context, userID int64) (string, error) {
+	const op = "storage.sqlstore.GetLocale"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT locale FROM users WHERE user_id = ?`
+
+	var locale sql.NullString
+	if err := s.conn.QueryRowContext(ctx, query, userID).Scan(&locale); err != nil {
+		return "", fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return locale.String, nil
+}
+
+func (s *Storage) SetLocale(ctx context.Context, userID int64, locale string) error {
+	const op = "storage.sqlstore.SetLocale"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `UPDATE users SET locale = ? WHERE user_id = ?`
+
+	result, err := s.conn.ExecContext(ctx, query, sql.NullString{String: locale, Valid: locale != ""}, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: user %w", op, storage.ErrNotFound)
+	}
+
+	return nil
+}
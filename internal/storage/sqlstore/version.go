@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+// Every write to a workout, its exercises or its sets bumps the workout's
+// version in the same transaction, so the version identifies the state of the
+// whole workout.
+const (
+	bumpVersion           = `UPDATE workouts SET version = version + 1 WHERE workout_id = ?`
+	bumpVersionByExercise = `UPDATE workouts SET version = version + 1
+			 WHERE workout_id = (SELECT workout_id FROM workout_exercises WHERE workout_exercise_id = ?)`
+	bumpVersionBySet = `UPDATE workouts SET version = version + 1
+			 WHERE workout_id = (SELECT we.workout_id FROM sets s
+				 JOIN workout_exercises we ON we.workout_exercise_id = s.workout_exercise_id
+				 WHERE s.set_id = ?)`
+	// bumpVersionIfOwns bumps workout_id from version only if the workout
+	// exercise belongs to it.
+	bumpVersionIfOwns = `UPDATE workouts SET version = version + 1
+			 WHERE workout_id = ? AND version = ? AND deleted_at IS NULL
+			 AND EXISTS (SELECT 1 FROM workout_exercises
+				 WHERE workout_exercise_id = ? AND workout_id = workouts.workout_id)`
+)
+
+// checkVersion explains a conditional write on workoutID that matched no
+// rows: it returns ErrNotFound if the workout is gone, ErrVersionMismatch if
+// it has moved on from version, and nil if neither is the reason.
+func (s *Storage) checkVersion(ctx context.Context, q queryer, workoutID, version int64) error {
+	var current int64
+	err := q.QueryRowContext(ctx, `SELECT version FROM workouts WHERE workout_id = ? AND deleted_at IS NULL`, workoutID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("workout %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return s.mapError(err)
+	}
+	if current != version {
+		return fmt.Errorf("workout is at version %d, not %d: %w", current, version, storage.ErrVersionMismatch)
+	}
+	return nil
+}
+
+// bumpVersionIfOwnsExercise runs bumpVersionIfOwns in tx and turns a miss
+// into the matching storage error.
+func (s *Storage) bumpVersionIfOwnsExercise(ctx context.Context, tx txn, workoutID, version, workoutExerciseID int64) error {
+	result, err := tx.ExecContext(ctx, bumpVersionIfOwns, workoutID, version, workoutExerciseID)
+	if err != nil {
+		return s.mapError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return s.mapError(err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	if err := s.checkVersion(ctx, tx, workoutID, version); err != nil {
+		return err
+	}
+	return fmt.Errorf("workout exercise %w", storage.ErrNotFound)
+}
@@ -0,0 +1,219 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+const workoutDetailsQuery = `SELECT w.workout_id, w.user_id, w.workout_date, w.workout_start_time, w.workout_end_time, w.notes, w.photo, w.version,
+			 we.workout_exercise_id, we.exercise_id, ae.name, ae.bodyweight,
+			 s.set_id, s.repetitions, s.weight, s.set_type, s.rpe, s.rir, s.rest_seconds, s.tempo,
+			 s.duration_seconds, s.distance_meters
+			 FROM workouts w
+			 LEFT JOIN workout_exercises we ON we.workout_id = w.workout_id
+			 LEFT JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+			 LEFT JOIN sets s ON s.workout_exercise_id = we.workout_exercise_id`
+
+func (s *Storage) GetWorkoutDetails(ctx context.Context, workoutID int64) (*storage.WorkoutDetails, error) {
+	const op = "storage.sqlstore.GetWorkoutDetails"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := workoutDetailsQuery + `
+			 WHERE w.workout_id = ? AND w.deleted_at IS NULL
+			 ORDER BY we.workout_exercise_id, s.set_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, workoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	workouts, err := scanWorkoutDetails(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if len(workouts) == 0 {
+		return nil, fmt.Errorf("%s: workout %w", op, storage.ErrNotFound)
+	}
+	workout := &workouts[0]
+	if err := s.openWorkout(ctx, s.conn, &workout.WorkoutInfo); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.setBodyWeight(ctx, workout); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workout, nil
+}
+
+// GetWorkoutSummariesInRange returns the workouts of userID dated from from to
+// to with their exercises but without sets, for listings that only name the
+// exercises.
+func (s *Storage) GetWorkoutSummariesInRange(ctx context.Context, userID int64, from, to string) ([]storage.WorkoutDetails, error) {
+	const op = "storage.sqlstore.GetWorkoutSummariesInRange"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT w.workout_id, w.user_id, w.workout_date, w.workout_start_time, w.workout_end_time, w.notes, w.photo, w.version,
+			 we.workout_exercise_id, we.exercise_id, ae.name, ae.bodyweight
+			 FROM workouts w
+			 LEFT JOIN workout_exercises we ON we.workout_id = w.workout_id
+			 LEFT JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+			 WHERE w.user_id = ? AND w.deleted_at IS NULL AND w.workout_date BETWEEN ? AND ?
+			 ORDER BY w.workout_date, w.workout_id, we.workout_exercise_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workouts []storage.WorkoutDetails
+	for rows.Next() {
+		var (
+			workout           storage.WorkoutInfo
+			workoutExerciseID sql.NullInt64
+			exerciseID        sql.NullInt64
+			exerciseName      sql.NullString
+			bodyweight        sql.NullBool
+		)
+		err := rows.Scan(&workout.WorkoutID, &workout.UserID, &workout.Date, &workout.StartTime, &workout.EndTime,
+			&workout.Notes, &workout.Photo, &workout.Version, &workoutExerciseID, &exerciseID, &exerciseName, &bodyweight)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		if len(workouts) == 0 || workouts[len(workouts)-1].WorkoutID != workout.WorkoutID {
+			workouts = append(workouts, storage.WorkoutDetails{WorkoutInfo: workout})
+		}
+		if !workoutExerciseID.Valid {
+			continue
+		}
+		current := &workouts[len(workouts)-1]
+		current.Exercises = append(current.Exercises, storage.WorkoutExerciseDetails{
+			WorkoutExerciseID: workoutExerciseID.Int64,
+			ExerciseID:        exerciseID.Int64,
+			ExerciseName:      exerciseName.String,
+			Bodyweight:        bodyweight.Bool,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range workouts {
+		if err := s.openWorkout(ctx, s.conn, &workouts[i].WorkoutInfo); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return workouts, nil
+}
+
+// setBodyWeight gives workout the body weight of its user on its day and its
+// bodyweight sets their effective load. Body weights may be sealed, so this
+// is done here rather than in SQL.
+func (s *Storage) setBodyWeight(ctx context.Context, workout *storage.WorkoutDetails) error {
+	bodyWeight, err := s.bodyWeightOn(ctx, s.conn, workout.UserID, workout.Date)
+	if err != nil || bodyWeight == nil {
+		return err
+	}
+	workout.BodyWeight = bodyWeight
+	for i := range workout.Exercises {
+		exercise := &workout.Exercises[i]
+		if !exercise.Bodyweight {
+			continue
+		}
+		for j := range exercise.Sets {
+			load := *bodyWeight + exercise.Sets[j].Weight
+			exercise.Sets[j].EffectiveLoad = &load
+		}
+	}
+	return nil
+}
+
+// scanWorkoutDetails folds the flat rows of workoutDetailsQuery back into
+// workouts. Rows must be ordered so that a workout's rows, and an exercise's
+// rows within it, are contiguous.
+func scanWorkoutDetails(rows *sql.Rows) ([]storage.WorkoutDetails, error) {
+	var workouts []storage.WorkoutDetails
+	for rows.Next() {
+		var (
+			workout           storage.WorkoutInfo
+			workoutExerciseID sql.NullInt64
+			exerciseID        sql.NullInt64
+			exerciseName      sql.NullString
+			bodyweight        sql.NullBool
+			setID             sql.NullInt64
+			repetitions       sql.NullInt64
+			weight            sql.NullFloat64
+			details           storage.SetDetails
+			setType           sql.NullString
+		)
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+			&workoutExerciseID,
+			&exerciseID,
+			&exerciseName,
+			&bodyweight,
+			&setID,
+			&repetitions,
+			&weight,
+			&setType,
+			&details.RPE,
+			&details.RIR,
+			&details.RestSeconds,
+			&details.Tempo,
+			&details.DurationSeconds,
+			&details.DistanceMeters,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(workouts) == 0 || workouts[len(workouts)-1].WorkoutID != workout.WorkoutID {
+			workouts = append(workouts, storage.WorkoutDetails{WorkoutInfo: workout})
+		}
+		current := &workouts[len(workouts)-1]
+		if !workoutExerciseID.Valid {
+			continue
+		}
+
+		exercises := current.Exercises
+		if len(exercises) == 0 || exercises[len(exercises)-1].WorkoutExerciseID != workoutExerciseID.Int64 {
+			current.Exercises = append(current.Exercises, storage.WorkoutExerciseDetails{
+				WorkoutExerciseID: workoutExerciseID.Int64,
+				ExerciseID:        exerciseID.Int64,
+				ExerciseName:      exerciseName.String,
+				Bodyweight:        bodyweight.Bool,
+			})
+		}
+		if !setID.Valid {
+			continue
+		}
+
+		exercise := &current.Exercises[len(current.Exercises)-1]
+		details.Type = storage.SetType(setType.String)
+		details.Derive()
+		set := storage.SetInfo{
+			SetID:             setID.Int64,
+			WorkoutExerciseID: workoutExerciseID.Int64,
+			Repetitions:       int(repetitions.Int64),
+			Weight:            weight.Float64,
+			SetDetails:        details,
+		}
+		exercise.Sets = append(exercise.Sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return workouts, nil
+}
@@ -0,0 +1,209 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddWorkoutExercise(ctx context.Context, workoutExercise storage.WorkoutExercise) (int64, error) {
+	const op = "storage.sqlstore.AddWorkoutExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if workoutExercise.WorkoutID == 0 || workoutExercise.ExerciseID == 0 {
+		return 0, fmt.Errorf("%s: workout ID or exercise ID is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workout_exercises (workout_id, exercise_id) VALUES (?, ?)
+			 RETURNING workout_exercise_id`
+	var workoutExerciseID int64
+	err = tx.QueryRowContext(ctx, query, workoutExercise.WorkoutID, workoutExercise.ExerciseID).Scan(&workoutExerciseID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if _, err := tx.ExecContext(ctx, bumpVersion, workoutExercise.WorkoutID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return workoutExerciseID, nil
+}
+
+func (s *Storage) AddWorkoutExercises(ctx context.Context, workoutExercises []storage.WorkoutExercise) error {
+	const op = "storage.sqlstore.AddWorkoutExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workout_exercises (workout_id, exercise_id) VALUES (?, ?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	bump, err := tx.PrepareContext(ctx, bumpVersion)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer bump.Close()
+
+	for _, workoutExercise := range workoutExercises {
+		if workoutExercise.WorkoutID == 0 || workoutExercise.ExerciseID == 0 {
+			return fmt.Errorf("%s: workout ID or exercise ID is required", op)
+		}
+		_, err := stmt.ExecContext(ctx, workoutExercise.WorkoutID, workoutExercise.ExerciseID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to add workout exercise: %w", op, s.mapError(err))
+		}
+		if _, err := bump.ExecContext(ctx, workoutExercise.WorkoutID); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteWorkoutExercise removes an exercise, with its sets, from the workout
+// if the workout is still at version.
+func (s *Storage) DeleteWorkoutExercise(ctx context.Context, workoutID, version, workoutExerciseID int64) error {
+	const op = "storage.sqlstore.DeleteWorkoutExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := s.bumpVersionIfOwnsExercise(ctx, tx, workoutID, version, workoutExerciseID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	query := `DELETE FROM workout_exercises WHERE workout_exercise_id = ?`
+	if _, err := tx.ExecContext(ctx, query, workoutExerciseID); err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteWorkoutExercises(ctx context.Context, workoutExerciseIDs []int64) error {
+	const op = "storage.sqlstore.DeleteWorkoutExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM workout_exercises WHERE workout_exercise_id = ?`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	bump, err := tx.PrepareContext(ctx, bumpVersionByExercise)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer bump.Close()
+
+	for _, id := range workoutExerciseIDs {
+		if _, err := bump.ExecContext(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		_, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete workout exercise with ID %d: %w", op, id, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetWorkoutExercise(ctx context.Context, workoutExerciseID int64) (*storage.WorkoutExerciseInfo, error) {
+	const op = "storage.sqlstore.GetWorkoutExercise"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_exercise_id, workout_id, exercise_id 
+			 FROM workout_exercises WHERE workout_exercise_id = ?`
+
+	we := &storage.WorkoutExerciseInfo{}
+	err := s.conn.QueryRowContext(ctx, query, workoutExerciseID).Scan(
+		&we.WorkoutExerciseID,
+		&we.WorkoutID,
+		&we.ExerciseID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s: workout exercise %w", op, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return we, nil
+}
+
+func (s *Storage) GetWorkoutExercises(ctx context.Context, workoutID int64) ([]storage.WorkoutExerciseInfo, error) {
+	const op = "storage.sqlstore.GetWorkoutExercises"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_exercise_id, workout_id, exercise_id 
+			 FROM workout_exercises WHERE workout_id = ?
+			 ORDER BY workout_exercise_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, workoutID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workoutExercises []storage.WorkoutExerciseInfo
+	for rows.Next() {
+		var we storage.WorkoutExerciseInfo
+		err := rows.Scan(
+			&we.WorkoutExerciseID,
+			&we.WorkoutID,
+			&we.ExerciseID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		workoutExercises = append(workoutExercises, we)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	return workoutExercises, nil
+}
@@ -0,0 +1,387 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"diaryserver/internal/storage"
+)
+
+func (s *Storage) AddWorkout(ctx context.Context, workout storage.Workout) (int64, error) {
+	const op = "storage.sqlstore.AddWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	if workout.UserID == 0 {
+		return 0, fmt.Errorf("%s: user ID is required", op)
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workouts (user_id, workout_date, workout_start_time, workout_end_time, notes, photo) VALUES (?, ?, ?, ?, ?, ?)
+			 RETURNING workout_id`
+	notes, photo, sealed := workout.Notes, workout.Photo, s.sealsWorkout(workout)
+	if sealed {
+		notes, photo = "", ""
+	}
+	var workoutID int64
+	err = tx.QueryRowContext(ctx, query, workout.UserID, workout.Date, workout.StartTime, workout.EndTime, notes, photo).Scan(&workoutID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if sealed {
+		if err := s.sealWorkout(ctx, tx, workoutID, workout); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, completePlannedWorkout, workoutID, workout.UserID, workout.Date); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return workoutID, nil
+}
+
+func (s *Storage) AddWorkouts(ctx context.Context, workouts []storage.Workout) error {
+	const op = "storage.sqlstore.AddWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workouts (user_id, workout_date, workout_start_time, workout_end_time, notes, photo) VALUES (?, ?, ?, ?, ?, ?)
+			 RETURNING workout_id`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+	complete, err := tx.PrepareContext(ctx, completePlannedWorkout)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer complete.Close()
+
+	for _, workout := range workouts {
+		if workout.UserID == 0 {
+			return fmt.Errorf("%s: user ID is required", op)
+		}
+		notes, photo, sealed := workout.Notes, workout.Photo, s.sealsWorkout(workout)
+		if sealed {
+			notes, photo = "", ""
+		}
+		var workoutID int64
+		err := stmt.QueryRowContext(ctx, workout.UserID, workout.Date, workout.StartTime, workout.EndTime, notes, photo).Scan(&workoutID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to add workout for user %d: %w", op, workout.UserID, s.mapError(err))
+		}
+		if sealed {
+			if err := s.sealWorkout(ctx, tx, workoutID, workout); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if _, err := complete.ExecContext(ctx, workoutID, workout.UserID, workout.Date); err != nil {
+			return fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteWorkout moves the workout to the trash if it is still at version.
+func (s *Storage) DeleteWorkout(ctx context.Context, workoutID, version int64) error {
+	const op = "storage.sqlstore.DeleteWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `UPDATE workouts SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+			 WHERE workout_id = ? AND version = ? AND deleted_at IS NULL`
+
+	result, err := s.conn.ExecContext(ctx, query, workoutID, version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		if err := s.checkVersion(ctx, s.conn, workoutID, version); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteWorkouts(ctx context.Context, workoutIDs []int64) error {
+	const op = "storage.sqlstore.DeleteWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE workouts SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE workout_id = ? AND deleted_at IS NULL`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, id := range workoutIDs {
+		_, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: failed to delete workout with ID %d: %w", op, id, s.mapError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetWorkoutFromID(ctx context.Context, workout_ID int64) (*storage.WorkoutInfo, error) {
+	const op = "storage.sqlstore.GetWorkoutFromId"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version  
+			 FROM workouts WHERE workout_id = ? AND deleted_at IS NULL`
+
+	workout := &storage.WorkoutInfo{}
+	err := s.conn.QueryRowContext(ctx, query, workout_ID).Scan(
+		&workout.WorkoutID,
+		&workout.UserID,
+		&workout.Date,
+		&workout.StartTime,
+		&workout.EndTime,
+		&workout.Notes,
+		&workout.Photo,
+		&workout.Version,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s: workout %w", op, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if err := s.openWorkout(ctx, s.conn, workout); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workout, nil
+}
+
+func (s *Storage) GetAllWorkouts(ctx context.Context, userID int64) ([]storage.WorkoutInfo, error) {
+	const op = "storage.sqlstore.GetAllWorkouts"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version 
+			 FROM workouts WHERE user_id = ? AND deleted_at IS NULL
+			 ORDER BY workout_date`
+
+	rows, err := s.conn.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workouts []storage.WorkoutInfo
+	for rows.Next() {
+		var workout storage.WorkoutInfo
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		workouts = append(workouts, workout)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range workouts {
+		if err := s.openWorkout(ctx, s.conn, &workouts[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return workouts, nil
+}
+func (s *Storage) GetWorkoutsFromDate(ctx context.Context, user_id int64, date string) ([]storage.WorkoutInfo, error) {
+	const op = "storage.sqlstore.GetWorkoutsFromDate"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version
+				 FROM workouts WHERE user_id = ? AND workout_date = ? AND deleted_at IS NULL
+				 ORDER BY workout_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, user_id, date)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var workouts []storage.WorkoutInfo
+	for rows.Next() {
+		var workout storage.WorkoutInfo
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		workouts = append(workouts, workout)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for i := range workouts {
+		if err := s.openWorkout(ctx, s.conn, &workouts[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return workouts, nil
+}
+
+// GetCalendarDays returns up to limit days between from and to (inclusive) on
+// which the user has workouts, in date order, each with its workouts sorted by
+// start time.
+func (s *Storage) GetCalendarDays(ctx context.Context, userID int64, from, to string, limit int) ([]storage.CalendarDay, error) {
+	const op = "storage.sqlstore.GetCalendarDays"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	query := `SELECT workout_id, user_id, workout_date, workout_start_time, workout_end_time, notes, photo, version
+			 FROM workouts
+			 WHERE user_id = ? AND deleted_at IS NULL AND workout_date IN (
+				 SELECT DISTINCT workout_date FROM workouts
+				 WHERE user_id = ? AND deleted_at IS NULL AND workout_date >= ? AND workout_date <= ?
+				 ORDER BY workout_date
+				 LIMIT ?)
+			 ORDER BY workout_date, workout_start_time, workout_id`
+
+	rows, err := s.conn.QueryContext(ctx, query, userID, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	defer rows.Close()
+
+	var days []storage.CalendarDay
+	for rows.Next() {
+		var workout storage.WorkoutInfo
+		err := rows.Scan(
+			&workout.WorkoutID,
+			&workout.UserID,
+			&workout.Date,
+			&workout.StartTime,
+			&workout.EndTime,
+			&workout.Notes,
+			&workout.Photo,
+			&workout.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+		}
+		if len(days) == 0 || days[len(days)-1].Date != workout.Date {
+			days = append(days, storage.CalendarDay{Date: workout.Date})
+		}
+		days[len(days)-1].Workouts = append(days[len(days)-1].Workouts, workout)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	for _, day := range days {
+		for i := range day.Workouts {
+			if err := s.openWorkout(ctx, s.conn, &day.Workouts[i]); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// PartialUpdateWorkout applies patch if the workout is still at version.
+func (s *Storage) PartialUpdateWorkout(ctx context.Context, workoutID, version int64, patch storage.WorkoutPatch) error {
+	const op = "storage.sqlstore.PartialUpdateWorkout"
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+	columns := patch.Columns()
+	if len(columns) == 0 {
+		if err := s.checkVersion(ctx, s.conn, workoutID, version); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	if err := s.sealColumns(ctx, s.conn, workoutID, columns); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	query, args := updateWorkoutQuery(columns, workoutID, version)
+	result, err := s.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, s.mapError(err))
+	}
+	if affected == 0 {
+		if err := s.checkVersion(ctx, s.conn, workoutID, version); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// updateWorkoutQuery returns the UPDATE that writes columns to workoutID if it
+// is still at version and bumps the version, with its arguments.
+func updateWorkoutQuery(columns []storage.PatchColumn, workoutID, version int64) (string, []any) {
+	// 	UPDATE workouts
+	// 	SET field1 = ?, field2 = ?, ..., version = version + 1
+	// 	WHERE workout_id = ? AND version = ?
+	args := make([]any, 0, len(columns)+2)
+	setParts := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		args = append(args, column.Value)
+		setParts = append(setParts, column.Column+" = ?")
+	}
+	setParts = append(setParts, "version = version + 1")
+	args = append(args, workoutID, version)
+	query := "UPDATE workouts SET " + strings.Join(setParts, ", ") + " WHERE workout_id = ? AND version = ? AND deleted_at IS NULL"
+	return query, args
+}
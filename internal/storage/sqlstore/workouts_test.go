@@ -0,0 +1,20 @@
+package sqlstore
+
+import (
+	"slices"
+	"testing"
+
+	"diaryserver/internal/storage"
+)
+
+func TestUpdateWorkoutQuery(t *testing.T) {
+	columns := []storage.PatchColumn{{Column: "notes", Value: "n"}, {Column: "workout_end_time", Value: ""}}
+	query, args := updateWorkoutQuery(columns, 7, 3)
+	want := "UPDATE workouts SET notes = ?, workout_end_time = ?, version = version + 1 WHERE workout_id = ? AND version = ? AND deleted_at IS NULL"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if want := []any{"n", "", int64(7), int64(3)}; !slices.Equal(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
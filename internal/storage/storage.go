@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type Storage interface {
+	Repos
+	BlacklistedTokens
+	Search
+	// WithTx runs fn in a single transaction, for writes that span several
+	// repositories. The changes made through tx are committed if fn returns
+	// nil and rolled back otherwise; fn's error is returned unchanged.
+	WithTx(ctx context.Context, fn func(tx Repos) error) error
+	Close() error
+}
+
+// Repos are the repositories that can take part in a transaction.
+type Repos interface {
+	Users
+	Workouts
+	WorkoutExercises
+	Sets
+	AllowedExercises
+	ExerciseCatalog
+	ExerciseTranslations
+	BodyWeights
+	Templates
+	Programs
+	PlannedWorkouts
+	RecurringWorkouts
+}
+
+type Users interface {
+	AddUser(ctx context.Context, user User) error
+	AddUsers(ctx context.Context, users []User) error
+	DeleteUser(ctx context.Context, username string) error
+	DeleteUsers(ctx context.Context, usernames []string) error
+	DeleteAllUsers(ctx context.Context) error
+	GetUser(ctx context.Context, username string) (*UserInfo, error)
+	GetUsers(ctx context.Context) ([]UserInfo, error)
+	GetWeightUnit(ctx context.Context, userID int64) (WeightUnit, error)
+	SetWeightUnit(ctx context.Context, userID int64, unit WeightUnit) error
+	// GetLocale returns the locale the user prefers, "" when they left it to
+	// their client. SetLocale with "" clears it.
+	GetLocale(ctx context.Context, userID int64) (string, error)
+	SetLocale(ctx context.Context, userID int64, locale string) error
+}
+
+// Methods that take a version only apply when the workout is still at that
+// version and fail with ErrVersionMismatch otherwise.
+type Workouts interface {
+	AddWorkout(ctx context.Context, workout Workout) (int64, error)
+	AddWorkouts(ctx context.Context, workouts []Workout) error
+	DeleteWorkout(ctx context.Context, workoutID, version int64) error
+	DeleteWorkouts(ctx context.Context, workoutIDs []int64) error
+	GetWorkoutFromID(ctx context.Context, workoutID int64) (*WorkoutInfo, error)
+	GetAllWorkouts(ctx context.Context, userID int64) ([]WorkoutInfo, error)
+	GetWorkoutsFromDate(ctx context.Context, userID int64, date string) ([]WorkoutInfo, error)
+	PartialUpdateWorkout(ctx context.Context, workoutID, version int64, patch WorkoutPatch) error
+	GetWorkoutDetails(ctx context.Context, workoutID int64) (*WorkoutDetails, error)
+	GetWorkoutSummariesInRange(ctx context.Context, userID int64, from, to string) ([]WorkoutDetails, error)
+	GetCalendarDays(ctx context.Context, userID int64, from, to string, limit int) ([]CalendarDay, error)
+	GetDeletedWorkouts(ctx context.Context, userID int64) ([]TrashedWorkout, error)
+	GetDeletedWorkout(ctx context.Context, workoutID int64) (*TrashedWorkout, error)
+	RestoreWorkout(ctx context.Context, workoutID int64) error
+	PurgeDeletedWorkouts(ctx context.Context, olderThan time.Duration) ([]WorkoutInfo, error)
+}
+
+type WorkoutExercises interface {
+	AddWorkoutExercise(ctx context.Context, workoutExercise WorkoutExercise) (int64, error)
+	AddWorkoutExercises(ctx context.Context, workoutExercises []WorkoutExercise) error
+	DeleteWorkoutExercise(ctx context.Context, workoutID, version, workoutExerciseID int64) error
+	DeleteWorkoutExercises(ctx context.Context, workoutExerciseIDs []int64) error
+	GetWorkoutExercise(ctx context.Context, workoutExerciseID int64) (*WorkoutExerciseInfo, error)
+	GetWorkoutExercises(ctx context.Context, workoutID int64) ([]WorkoutExerciseInfo, error)
+}
+
+type Sets interface {
+	AddSet(ctx context.Context, set Set) error
+	AddSets(ctx context.Context, sets []Set) error
+	DeleteSet(ctx context.Context, setID int64) error
+	DeleteSets(ctx context.Context, setIDs []int64) error
+	GetSet(ctx context.Context, setID int64) (*SetInfo, error)
+	GetSets(ctx context.Context, workoutExerciseID int64) ([]SetInfo, error)
+	ReplaceSets(ctx context.Context, workoutID, version, workoutExerciseID int64, sets []SetInfo) error
+}
+
+type AllowedExercises interface {
+	AddAllowedExercise(ctx context.Context, exercise AllowedExercise) error
+	AddAllowedExercises(ctx context.Context, exercises []AllowedExercise) error
+	DeleteAllowedExercise(ctx context.Context, name string) error
+	DeleteAllowedExercises(ctx context.Context, names []string) error
+	GetAllowedExercise(ctx context.Context, id int64) (AllowedExerciseInfo, error)
+	// GetAllowedExercises returns the global catalog, without custom
+	// exercises.
+	GetAllowedExercises(ctx context.Context) ([]AllowedExerciseInfo, error)
+	// Custom exercises belong to ownerID; the methods taking one only see
+	// that owner's exercises and fail with ErrNotFound for any other.
+	AddCustomExercise(ctx context.Context, ownerID int64, exercise AllowedExercise) (int64, error)
+	GetCustomExercises(ctx context.Context, ownerID int64) ([]AllowedExerciseInfo, error)
+	UpdateCustomExercise(ctx context.Context, ownerID, exerciseID int64, exercise AllowedExercise) error
+	DeleteCustomExercise(ctx context.Context, ownerID, exerciseID int64) error
+}
+
+// ExerciseCatalog classifies exercises by muscle group, equipment and
+// movement pattern.
+type ExerciseCatalog interface {
+	GetTaxonomy(ctx context.Context) (*Taxonomy, error)
+	// GetCatalog returns the global exercises and the custom exercises of
+	// userID that match filter, global ones first.
+	GetCatalog(ctx context.Context, userID int64, filter CatalogFilter) ([]CatalogExercise, error)
+	// SetExerciseTaxonomy replaces the taxonomy of a custom exercise of
+	// ownerID. An unknown code fails with ErrForeignKey.
+	SetExerciseTaxonomy(ctx context.Context, ownerID, exerciseID int64, taxonomy ExerciseTaxonomy) error
+}
+
+// ExerciseTranslations hold the names and descriptions of global exercises in
+// locales other than DefaultLocale.
+type ExerciseTranslations interface {
+	// GetLocales returns the locales with at least one translation.
+	GetLocales(ctx context.Context) ([]string, error)
+	GetExerciseTranslations(ctx context.Context, locale string) ([]ExerciseTranslation, error)
+	GetTranslationsOfExercise(ctx context.Context, exerciseID int64) ([]ExerciseTranslation, error)
+	// PutExerciseTranslation adds or replaces a translation. It fails with
+	// ErrNotFound unless the exercise is in the global catalog.
+	PutExerciseTranslation(ctx context.Context, translation ExerciseTranslation) error
+	DeleteExerciseTranslation(ctx context.Context, exerciseID int64, locale string) error
+}
+
+// Templates belong to a user; the methods taking userID fail with
+// ErrNotFound for anyone else's. Names are unique per user.
+type Templates interface {
+	AddTemplate(ctx context.Context, template WorkoutTemplate) (int64, error)
+	GetTemplates(ctx context.Context, userID int64) ([]WorkoutTemplate, error)
+	GetTemplate(ctx context.Context, userID, templateID int64) (*WorkoutTemplate, error)
+	// UpdateTemplate replaces the name, notes and exercises of a template.
+	UpdateTemplate(ctx context.Context, userID, templateID int64, template WorkoutTemplate) error
+	DeleteTemplate(ctx context.Context, userID, templateID int64) error
+}
+
+// Programs belong to a user like templates do.
+type Programs interface {
+	AddProgram(ctx context.Context, program Program) (int64, error)
+	GetPrograms(ctx context.Context, userID int64) ([]Program, error)
+	GetProgram(ctx context.Context, userID, programID int64) (*Program, error)
+	// UpdateProgram replaces a program. Existing enrollments keep the
+	// workouts planned from the old one.
+	UpdateProgram(ctx context.Context, userID, programID int64, program Program) error
+	// DeleteProgram deletes a program with its enrollments and their
+	// planned workouts. Logged workouts stay.
+	DeleteProgram(ctx context.Context, userID, programID int64) error
+	// AddEnrollment enrolls enrollment.UserID in one of their programs and
+	// plans a workout for every day of it from enrollment.StartDate.
+	AddEnrollment(ctx context.Context, enrollment Enrollment) (int64, error)
+	GetEnrollments(ctx context.Context, userID int64) ([]Enrollment, error)
+	GetEnrollment(ctx context.Context, userID, enrollmentID int64) (*Enrollment, error)
+	DeleteEnrollment(ctx context.Context, userID, enrollmentID int64) error
+}
+
+// PlannedWorkouts are completed as workouts are logged: adding a workout
+// links it to the earliest open plan of its user on its date.
+type PlannedWorkouts interface {
+	GetPlannedWorkouts(ctx context.Context, userID int64, from, to string) ([]PlannedWorkout, error)
+	GetPlannedWorkout(ctx context.Context, userID, plannedWorkoutID int64) (*PlannedWorkout, error)
+	// LinkPlannedWorkout marks a plan completed by workoutID, moving the
+	// workout off any other plan it completed.
+	LinkPlannedWorkout(ctx context.Context, plannedWorkoutID, workoutID int64) error
+}
+
+// RecurringWorkouts are stored as rules; their occurrences are expanded by
+// the caller with RecurringWorkout.Occurrences.
+type RecurringWorkouts interface {
+	AddRecurringWorkout(ctx context.Context, workout RecurringWorkout) (int64, error)
+	// GetRecurringWorkouts returns the series of userID that start by to,
+	// with the exceptions of occurrences dated or moved between from and to.
+	GetRecurringWorkouts(ctx context.Context, userID int64, from, to string) ([]RecurringWorkout, error)
+	GetRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64) (*RecurringWorkout, error)
+	// UpdateRecurringWorkout replaces a series, dropping its skips and moves.
+	// Occurrences converted into workouts stay linked to them.
+	UpdateRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64, workout RecurringWorkout) error
+	DeleteRecurringWorkout(ctx context.Context, userID, recurringWorkoutID int64) error
+	// SetRecurrenceException skips or moves an occurrence, replacing what
+	// was done to it before.
+	SetRecurrenceException(ctx context.Context, userID, recurringWorkoutID int64, exception RecurrenceException) error
+	// DeleteRecurrenceException puts an occurrence back where the rule has
+	// it.
+	DeleteRecurrenceException(ctx context.Context, userID, recurringWorkoutID int64, date string) error
+	// LinkRecurrenceWorkout marks the occurrence on date completed by
+	// workoutID.
+	LinkRecurrenceWorkout(ctx context.Context, recurringWorkoutID int64, date string, workoutID int64) error
+}
+
+// BodyWeights is the log of users' body weights. Adding an entry for a day
+// that already has one replaces it.
+type BodyWeights interface {
+	AddBodyWeight(ctx context.Context, entry BodyWeight) error
+	DeleteBodyWeight(ctx context.Context, userID int64, date string) error
+	GetBodyWeights(ctx context.Context, userID int64, from, to string) ([]BodyWeight, error)
+}
+
+type BlacklistedTokens interface {
+	AddBlacklistedToken(ctx context.Context, token string, expirationTime time.Time) error
+	IsTokenBlacklisted(ctx context.Context, token string) (bool, error)
+	RemoveExpiredTokens(ctx context.Context) error
+}
+
+type Search interface {
+	SearchWorkouts(ctx context.Context, userID int64, filter SearchFilter) ([]SearchResult, error)
+}
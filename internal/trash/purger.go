@@ -0,0 +1,69 @@
+// Package trash empties the workout trash bin once entries are old enough.
+package trash
+
+import (
+	"context"
+	"diaryserver/internal/config"
+	"diaryserver/internal/storage"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type Purger struct {
+	store        storage.Workouts
+	cfg          config.Trash
+	picturesPath string
+	log          *slog.Logger
+}
+
+func NewPurger(store storage.Workouts, cfg config.Trash, picturesPath string, log *slog.Logger) *Purger {
+	return &Purger{store: store, cfg: cfg, picturesPath: picturesPath, log: log}
+}
+
+// Run purges once right away and then every cfg.PurgeInterval until ctx is
+// done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		p.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard-deletes workouts that have been in the trash longer than
+// cfg.Retention, together with their photos.
+func (p *Purger) Purge(ctx context.Context) {
+	purged, err := p.store.PurgeDeletedWorkouts(ctx, p.cfg.Retention)
+	if err != nil {
+		p.log.Error("failed to purge trash", "error", err)
+		return
+	}
+	if len(purged) == 0 {
+		return
+	}
+	for _, workout := range purged {
+		p.removePhoto(workout.Photo)
+	}
+	p.log.Info("trash purged", slog.Int("workouts", len(purged)))
+}
+
+func (p *Purger) removePhoto(photo string) {
+	// Only ever delete a file directly inside the pictures directory.
+	name := filepath.Base(photo)
+	if photo == "" || p.picturesPath == "" || name == "." || name == string(filepath.Separator) {
+		return
+	}
+	err := os.Remove(filepath.Join(p.picturesPath, name))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		p.log.Error("failed to remove photo", "photo", photo, "error", err)
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE workouts ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_workouts_deleted_at ON workouts(deleted_at) WHERE deleted_at IS NOT NULL;
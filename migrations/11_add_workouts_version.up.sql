@@ -0,0 +1 @@
+ALTER TABLE workouts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
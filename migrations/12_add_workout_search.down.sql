@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS workout_search_allowed_exercises_update;
+DROP TRIGGER IF EXISTS workout_search_workout_exercises_update;
+DROP TRIGGER IF EXISTS workout_search_workout_exercises_delete;
+DROP TRIGGER IF EXISTS workout_search_workout_exercises_insert;
+DROP TRIGGER IF EXISTS workout_search_workouts_delete;
+DROP TRIGGER IF EXISTS workout_search_workouts_update;
+DROP TRIGGER IF EXISTS workout_search_workouts_insert;
+DROP TABLE IF EXISTS workout_search;
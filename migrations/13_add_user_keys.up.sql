@@ -0,0 +1,25 @@
+-- Data keys for field encryption, one per user, each wrapped by the master
+-- key whose ID is stored next to it. Deleting a user deletes their key.
+CREATE TABLE IF NOT EXISTS user_keys (
+    user_id INTEGER PRIMARY KEY,
+    wrapped_key BLOB NOT NULL,
+    master_key_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Encrypted notes cannot be searched; index them as empty instead of
+-- indexing the ciphertext.
+DROP TRIGGER IF EXISTS workout_search_workouts_insert;
+CREATE TRIGGER workout_search_workouts_insert AFTER INSERT ON workouts
+BEGIN
+    INSERT INTO workout_search (rowid, notes, exercises)
+    VALUES (NEW.workout_id, CASE WHEN NEW.notes GLOB 'enc:v1:*' THEN '' ELSE COALESCE(NEW.notes, '') END, '');
+END;
+
+DROP TRIGGER IF EXISTS workout_search_workouts_update;
+CREATE TRIGGER workout_search_workouts_update AFTER UPDATE OF notes ON workouts
+BEGIN
+    UPDATE workout_search SET notes = CASE WHEN NEW.notes GLOB 'enc:v1:*' THEN '' ELSE COALESCE(NEW.notes, '') END
+    WHERE rowid = NEW.workout_id;
+END;
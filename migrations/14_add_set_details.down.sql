@@ -0,0 +1,5 @@
+ALTER TABLE sets DROP COLUMN tempo;
+ALTER TABLE sets DROP COLUMN rest_seconds;
+ALTER TABLE sets DROP COLUMN rir;
+ALTER TABLE sets DROP COLUMN rpe;
+ALTER TABLE sets DROP COLUMN set_type;
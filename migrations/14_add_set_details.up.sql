@@ -0,0 +1,8 @@
+-- What kind of set it was and how hard it felt. Everything but the type is
+-- optional; existing sets become working sets.
+ALTER TABLE sets ADD COLUMN set_type TEXT NOT NULL DEFAULT 'working'
+    CHECK (set_type IN ('warmup', 'working', 'drop', 'failure', 'amrap'));
+ALTER TABLE sets ADD COLUMN rpe REAL;
+ALTER TABLE sets ADD COLUMN rir INTEGER;
+ALTER TABLE sets ADD COLUMN rest_seconds INTEGER;
+ALTER TABLE sets ADD COLUMN tempo TEXT;
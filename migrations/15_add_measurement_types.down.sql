@@ -0,0 +1,8 @@
+-- Catalog entries still referenced by workouts are kept.
+DELETE FROM allowed_exercises
+WHERE name IN ('Бег', 'Гребля', 'Планка')
+  AND NOT EXISTS (SELECT 1 FROM workout_exercises we WHERE we.exercise_id = allowed_exercises.exercise_id);
+
+ALTER TABLE sets DROP COLUMN distance_meters;
+ALTER TABLE sets DROP COLUMN duration_seconds;
+ALTER TABLE allowed_exercises DROP COLUMN measurement_type;
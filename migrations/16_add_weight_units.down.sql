@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS body_weights;
+ALTER TABLE allowed_exercises DROP COLUMN bodyweight;
+ALTER TABLE users DROP COLUMN weight_unit;
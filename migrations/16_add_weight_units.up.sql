@@ -0,0 +1,17 @@
+-- Weights are stored in kilograms and converted to each user's unit at the
+-- API. Sets of bodyweight exercises record the load added to, or with a
+-- negative weight taken off, the user's body weight, logged per day.
+ALTER TABLE users ADD COLUMN weight_unit TEXT NOT NULL DEFAULT 'kg'
+    CHECK (weight_unit IN ('kg', 'lb'));
+ALTER TABLE allowed_exercises ADD COLUMN bodyweight INTEGER NOT NULL DEFAULT 0;
+
+UPDATE allowed_exercises SET bodyweight = 1 WHERE name IN ('Подтягивания', 'Отжимания');
+
+CREATE TABLE IF NOT EXISTS body_weights (
+    body_weight_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    measured_on TEXT NOT NULL,
+    weight REAL NOT NULL CHECK (weight > 0),
+    UNIQUE (user_id, measured_on),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,34 @@
+-- The global catalog has no room for custom exercises, so they go, together
+-- with the workout exercises and sets recorded with them.
+DELETE FROM workout_exercises
+WHERE exercise_id IN (SELECT exercise_id FROM allowed_exercises WHERE owner_id IS NOT NULL);
+DELETE FROM allowed_exercises WHERE owner_id IS NOT NULL;
+DELETE FROM sets
+WHERE workout_exercise_id NOT IN (SELECT workout_exercise_id FROM workout_exercises);
+
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE allowed_exercises_old (
+    exercise_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    measurement_type TEXT NOT NULL DEFAULT 'reps_weight'
+        CHECK (measurement_type IN ('reps_weight', 'reps', 'time', 'distance_time')),
+    bodyweight INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO allowed_exercises_old (exercise_id, name, description, measurement_type, bodyweight)
+SELECT exercise_id, name, description, measurement_type, bodyweight FROM allowed_exercises;
+DROP TABLE allowed_exercises;
+ALTER TABLE allowed_exercises_old RENAME TO allowed_exercises;
+
+PRAGMA legacy_alter_table = OFF;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_allowed_exercises_update AFTER UPDATE OF name ON allowed_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = workout_search.rowid), '')
+    WHERE rowid IN (SELECT workout_id FROM workout_exercises WHERE exercise_id = NEW.exercise_id);
+END;
@@ -0,0 +1,38 @@
+-- Users can add their own exercises next to the global catalog, which keeps
+-- owner_id NULL. Names are unique per owner, which needs the table rebuilt to
+-- drop the UNIQUE on name. The migrator connects without foreign keys, so
+-- dropping the old table leaves workout_exercises alone; legacy_alter_table
+-- keeps the rename from rejecting the search triggers that name the table.
+PRAGMA legacy_alter_table = ON;
+
+CREATE TABLE allowed_exercises_new (
+    exercise_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT,
+    measurement_type TEXT NOT NULL DEFAULT 'reps_weight'
+        CHECK (measurement_type IN ('reps_weight', 'reps', 'time', 'distance_time')),
+    bodyweight INTEGER NOT NULL DEFAULT 0,
+    owner_id INTEGER,
+    FOREIGN KEY (owner_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+INSERT INTO allowed_exercises_new (exercise_id, name, description, measurement_type, bodyweight)
+SELECT exercise_id, name, description, measurement_type, bodyweight FROM allowed_exercises;
+DROP TABLE allowed_exercises;
+ALTER TABLE allowed_exercises_new RENAME TO allowed_exercises;
+
+PRAGMA legacy_alter_table = OFF;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_allowed_exercises_global_name ON allowed_exercises(name) WHERE owner_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_allowed_exercises_owner_name ON allowed_exercises(owner_id, name) WHERE owner_id IS NOT NULL;
+
+-- Dropping the old table dropped its trigger keeping the search index in step
+-- with renamed exercises.
+CREATE TRIGGER IF NOT EXISTS workout_search_allowed_exercises_update AFTER UPDATE OF name ON allowed_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = workout_search.rowid), '')
+    WHERE rowid IN (SELECT workout_id FROM workout_exercises WHERE exercise_id = NEW.exercise_id);
+END;
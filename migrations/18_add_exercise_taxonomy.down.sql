@@ -0,0 +1,6 @@
+ALTER TABLE allowed_exercises DROP COLUMN movement_pattern_id;
+DROP TABLE IF EXISTS exercise_equipment;
+DROP TABLE IF EXISTS exercise_muscles;
+DROP TABLE IF EXISTS movement_patterns;
+DROP TABLE IF EXISTS equipment;
+DROP TABLE IF EXISTS muscle_groups;
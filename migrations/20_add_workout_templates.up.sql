@@ -0,0 +1,43 @@
+-- Templates are named, ordered lists of exercises with the sets to aim for.
+-- Starting a workout from one copies them into a new workout, so later edits
+-- to a template never touch recorded workouts. Target weights are in
+-- kilograms like those of sets.
+CREATE TABLE IF NOT EXISTS workout_templates (
+    template_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS template_exercises (
+    template_exercise_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    template_id INTEGER NOT NULL,
+    exercise_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (template_id, position),
+    FOREIGN KEY (template_id) REFERENCES workout_templates(template_id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id)
+);
+
+CREATE TABLE IF NOT EXISTS template_sets (
+    template_set_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    template_exercise_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL,
+    weight REAL NOT NULL,
+    set_type TEXT NOT NULL DEFAULT 'working'
+        CHECK (set_type IN ('warmup', 'working', 'drop', 'failure', 'amrap')),
+    rpe REAL,
+    rir INTEGER,
+    rest_seconds INTEGER,
+    tempo TEXT,
+    duration_seconds INTEGER,
+    distance_meters REAL,
+    UNIQUE (template_exercise_id, position),
+    FOREIGN KEY (template_exercise_id) REFERENCES template_exercises(template_exercise_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_exercises_exercise_id ON template_exercises(exercise_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS planned_workouts;
+DROP TABLE IF EXISTS training_maxes;
+DROP TABLE IF EXISTS program_enrollments;
+DROP TABLE IF EXISTS program_days;
+DROP TABLE IF EXISTS programs;
+ALTER TABLE template_sets DROP COLUMN percent_of_tm;
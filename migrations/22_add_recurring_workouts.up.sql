@@ -0,0 +1,36 @@
+-- Recurring workouts are planned with an RFC 5545 rule (RRULE) from a start
+-- date at a time of day, e.g. FREQ=WEEKLY;BYDAY=MO,WE,FR at 07:00:00. Their
+-- occurrences are expanded when the calendar is read, never stored.
+CREATE TABLE IF NOT EXISTS recurring_workouts (
+    recurring_workout_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    start_date TEXT NOT NULL,
+    start_time TEXT NOT NULL DEFAULT '',
+    rrule TEXT NOT NULL,
+    template_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES workout_templates(template_id) ON DELETE SET NULL
+);
+
+-- An exception changes one occurrence, keyed by the date the rule gives it:
+-- it is skipped or moved to another date and time. An occurrence converted
+-- into a workout links it, moved or not.
+CREATE TABLE IF NOT EXISTS recurrence_exceptions (
+    recurring_workout_id INTEGER NOT NULL,
+    occurrence_date TEXT NOT NULL,
+    action TEXT CHECK (action IN ('skip', 'move')),
+    moved_date TEXT,
+    moved_time TEXT,
+    workout_id INTEGER,
+    PRIMARY KEY (recurring_workout_id, occurrence_date),
+    FOREIGN KEY (recurring_workout_id) REFERENCES recurring_workouts(recurring_workout_id) ON DELETE CASCADE,
+    FOREIGN KEY (workout_id) REFERENCES workouts(workout_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_workouts_user_id ON recurring_workouts(user_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_workouts_template_id ON recurring_workouts(template_id);
+CREATE INDEX IF NOT EXISTS idx_recurrence_exceptions_moved_date ON recurrence_exceptions(moved_date);
+CREATE INDEX IF NOT EXISTS idx_recurrence_exceptions_workout_id ON recurrence_exceptions(workout_id);
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS workout_search_workouts_insert;
+CREATE TRIGGER workout_search_workouts_insert AFTER INSERT ON workouts
+BEGIN
+    INSERT INTO workout_search (rowid, notes, exercises)
+    VALUES (NEW.workout_id, CASE WHEN NEW.notes GLOB 'enc:v1:*' THEN '' ELSE COALESCE(NEW.notes, '') END, '');
+END;
+
+DROP TRIGGER IF EXISTS workout_search_workouts_update;
+CREATE TRIGGER workout_search_workouts_update AFTER UPDATE OF notes ON workouts
+BEGIN
+    UPDATE workout_search SET notes = CASE WHEN NEW.notes GLOB 'enc:v1:*' THEN '' ELSE COALESCE(NEW.notes, '') END
+    WHERE rowid = NEW.workout_id;
+END;
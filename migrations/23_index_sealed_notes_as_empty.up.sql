@@ -0,0 +1,15 @@
+-- Sealed values now carry their format version after "enc:v", so the search
+-- triggers treat notes of any version as encrypted and index them as empty.
+DROP TRIGGER IF EXISTS workout_search_workouts_insert;
+CREATE TRIGGER workout_search_workouts_insert AFTER INSERT ON workouts
+BEGIN
+    INSERT INTO workout_search (rowid, notes, exercises)
+    VALUES (NEW.workout_id, CASE WHEN NEW.notes GLOB 'enc:v[0-9]*:*' THEN '' ELSE COALESCE(NEW.notes, '') END, '');
+END;
+
+DROP TRIGGER IF EXISTS workout_search_workouts_update;
+CREATE TRIGGER workout_search_workouts_update AFTER UPDATE OF notes ON workouts
+BEGIN
+    UPDATE workout_search SET notes = CASE WHEN NEW.notes GLOB 'enc:v[0-9]*:*' THEN '' ELSE COALESCE(NEW.notes, '') END
+    WHERE rowid = NEW.workout_id;
+END;
@@ -0,0 +1,16 @@
+-- Encrypted weights cannot be turned back into numbers; run it only after
+-- every weight is stored in plain text.
+CREATE TABLE body_weights_real (
+    body_weight_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    measured_on TEXT NOT NULL,
+    weight REAL NOT NULL CHECK (weight > 0),
+    UNIQUE (user_id, measured_on),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+INSERT INTO body_weights_real (body_weight_id, user_id, measured_on, weight)
+SELECT body_weight_id, user_id, measured_on, CAST(weight AS REAL) FROM body_weights;
+
+DROP TABLE body_weights;
+ALTER TABLE body_weights_real RENAME TO body_weights;
@@ -0,0 +1,17 @@
+-- Body weights may be encrypted at rest, so a weight is stored as text: the
+-- number in kilograms, or the sealed number. SQLite cannot change a column's
+-- type in place, so the table is rebuilt.
+CREATE TABLE body_weights_text (
+    body_weight_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    measured_on TEXT NOT NULL,
+    weight TEXT NOT NULL,
+    UNIQUE (user_id, measured_on),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+INSERT INTO body_weights_text (body_weight_id, user_id, measured_on, weight)
+SELECT body_weight_id, user_id, measured_on, CAST(weight AS TEXT) FROM body_weights;
+
+DROP TABLE body_weights;
+ALTER TABLE body_weights_text RENAME TO body_weights;
@@ -1,3 +0,0 @@
-DELETE FROM users WHERE user_id IN (
-    SELECT user_id FROM users WHERE username LIKE 'user%' ORDER BY user_id LIMIT 10
-);
\ No newline at end of file
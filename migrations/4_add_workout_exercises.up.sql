@@ -1,8 +1 @@
-INSERT INTO workout_exercises (workout_id, exercise_id) VALUES
-(2, 4),
-(1, 2),
-(2, 1),
-(1, 5),
-(1, 1),
-(2, 2),
-(2, 5);
\ No newline at end of file
+-- Demo data now lives in the seed command (dataset "demo"); see migration 9.
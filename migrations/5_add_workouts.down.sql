@@ -0,0 +1 @@
+-- Demo data now lives in the seed command (dataset "demo"); see migration 9.
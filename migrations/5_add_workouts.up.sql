@@ -1,3 +1 @@
-INSERT INTO workouts (user_id, workout_date, workout_start_time, workout_end_time, notes, photo) VALUES
-(1, '2025-01-01', '08:00:04', '09:00:24', 'Тренировка на силу', ''),
-(1, '2025-01-01', '10:01:02', '11:00:03', 'Кардио тренировка', '');
+-- Demo data now lives in the seed command (dataset "demo"); see migration 9.
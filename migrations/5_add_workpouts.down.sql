@@ -1,5 +0,0 @@
-DELETE FROM workouts 
-WHERE (user_id, workout_date, workout_start_time, workout_end_time, notes, photo) IN (
-    (1, '2025-01-01', '08:00:04', '09:00:24', 'Тренировка на силу', ''),
-    (1, '2025-01-01', '10:01:02', '11:00:03', 'Кардио тренировка', '')
-);
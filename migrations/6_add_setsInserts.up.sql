@@ -1,43 +1 @@
-INSERT INTO sets (workout_exercise_id, repetitions, weight)
-VALUES
-  (1, 14, 45.0),
-  (1, 18, 60.0),
-  (1, 7, 35.0),
-  (1, 16, 67.5),
-  (1, 12, 82.5),
-
-  (2, 20, 75.5),
-  (2, 13, 52.5),
-  (2, 5, 45.0),
-  (2, 11, 32.5),
-  (2, 19, 67.5),
-
-  (3, 17, 52.5),
-  (3, 14, 85.0),
-  (3, 9, 57.5),
-  (3, 12, 50.0),
-  (3, 6, 65.0),
-
-  (4, 15, 40.0),
-  (4, 8, 75.0),
-  (4, 10, 90.0),
-  (4, 13, 60.0),
-  (4, 11, 47.5),
-
-  (5, 18, 82.5),
-  (5, 12, 72.5),
-  (5, 16, 65.0),
-  (5, 14, 55.0),
-  (5, 8, 40.0),
-
-  (6, 4, 95.0),
-  (6, 20, 45.0),
-  (6, 10, 90.0),
-  (6, 19, 40.0),
-  (6, 17, 52.5),
-
-  (7, 15, 75.0),
-  (7, 9, 67.5),
-  (7, 18, 55.0),
-  (7, 11, 40.0),
-  (7, 16, 62.5);
\ No newline at end of file
+-- Demo data now lives in the seed command (dataset "demo"); see migration 9.
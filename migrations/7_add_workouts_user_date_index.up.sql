@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_workouts_user_id_workout_date ON workouts(user_id, workout_date);
@@ -0,0 +1 @@
+-- Removed orphaned rows cannot be restored.
@@ -0,0 +1,10 @@
+-- Foreign keys were not enforced before, so ON DELETE CASCADE never fired.
+DELETE FROM workouts
+WHERE user_id NOT IN (SELECT user_id FROM users);
+
+DELETE FROM workout_exercises
+WHERE workout_id NOT IN (SELECT workout_id FROM workouts)
+   OR exercise_id NOT IN (SELECT exercise_id FROM allowed_exercises);
+
+DELETE FROM sets
+WHERE workout_exercise_id NOT IN (SELECT workout_exercise_id FROM workout_exercises);
@@ -0,0 +1 @@
+-- Deleted demo rows are not restored; run `seed demo` instead.
@@ -0,0 +1,35 @@
+-- Migrations 3-6 used to insert demo accounts user1..user10 into every
+-- database. Remove those accounts, matched on the exact seeded credentials,
+-- together with everything they own. Use `seed demo` to get them back.
+CREATE TEMP TABLE seeded_users AS
+SELECT user_id FROM users
+WHERE (username, email, password_hash) IN (
+    ('user1', 'user1@gmail.com', '$2a$10$.zHxf/qDCaDAO4kDm/BM3.GY1Ds/ZsUWfdRAi.bIf62ppHHD/ZL2a'),
+    ('user2', 'user2@gmail.com', '$2a$10$H/PDzYeYep8AIylp09pi.e/nf2kdw6Gj4KtZvFZANpzCojjIJISgu'),
+    ('user3', 'user3@gmail.com', '$2a$10$YrLcZKq/CJvVSl7HjBrsDulu2ZMGVE1FLX8NW.wfBpbss3xOdhkvm'),
+    ('user4', 'user4@gmail.com', '$2a$10$Ey3sOO5TT.8GFy59WhL8N.C6eK/HPK7xk66ZNMWjtZedR28x82/bK'),
+    ('user5', 'user5@gmail.com', '$2a$10$.L4jJ4p31D2rww7BHjgUwONgYXRjm.Zsn.VSMXhPjQKo9zfQCcwsq'),
+    ('user6', 'user6@gmail.com', '$2a$10$K2Y0SXOQcsYmyDtUqF7zgujRJmxLtK4eJ2XDmA1w2UBjcjLzTroM6'),
+    ('user7', 'user7@gmail.com', '$2a$10$GN7Zsoa7SQAJzx12tPmPgurpxS6gTcc969pIyzjYG1uKC2obcipgS'),
+    ('user8', 'user8@gmail.com', '$2a$10$00d9Oit8Qt3/bEFMCpkOzuqA5fIW0KRmZhTe17RqUK8S7bxrpZvQ6'),
+    ('user9', 'user9@gmail.com', '$2a$10$LqCGTjmCjETDsoH6sfADGOqdYorVOLGVUxdusp45BmMfegE0Y2SLu'),
+    ('user10', 'user10@gmail.com', '$2a$10$ANiZe5qU0sW.hfWg74t4BuMrCf3dM8K4.S12tKe4H8Bky/zoNlaSq')
+);
+
+DELETE FROM sets
+WHERE workout_exercise_id IN (
+    SELECT we.workout_exercise_id FROM workout_exercises we
+    JOIN workouts w ON w.workout_id = we.workout_id
+    WHERE w.user_id IN (SELECT user_id FROM seeded_users)
+);
+
+DELETE FROM workout_exercises
+WHERE workout_id IN (
+    SELECT workout_id FROM workouts WHERE user_id IN (SELECT user_id FROM seeded_users)
+);
+
+DELETE FROM workouts WHERE user_id IN (SELECT user_id FROM seeded_users);
+
+DELETE FROM users WHERE user_id IN (SELECT user_id FROM seeded_users);
+
+DROP TABLE seeded_users;
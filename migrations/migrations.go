@@ -0,0 +1,17 @@
+// Package migrations embeds the SQL migrations so the server can check and
+// apply them without the files being deployed next to the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var SQLite embed.FS
+
+// SQLiteNoFTS5 replaces SQLite migrations that need FTS5 for builds without
+// it.
+//
+//go:embed nofts5/*.sql
+var SQLiteNoFTS5 embed.FS
+
+//go:embed postgres/*.sql
+var Postgres embed.FS
@@ -0,0 +1,73 @@
+-- Builds without FTS5 run this migration in place of
+-- migrations/12_add_workout_search.up.sql. workout_search is a plain table
+-- with the columns of the index, so that the triggers keeping it in sync,
+-- here and in later migrations, still work; search reports itself
+-- unavailable.
+CREATE TABLE IF NOT EXISTS workout_search (
+    rowid INTEGER PRIMARY KEY,
+    notes TEXT,
+    exercises TEXT
+);
+
+INSERT INTO workout_search (rowid, notes, exercises)
+SELECT w.workout_id, COALESCE(w.notes, ''),
+       COALESCE((SELECT group_concat(ae.name, ' ')
+                 FROM workout_exercises we
+                 JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+                 WHERE we.workout_id = w.workout_id), '')
+FROM workouts w;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workouts_insert AFTER INSERT ON workouts
+BEGIN
+    INSERT INTO workout_search (rowid, notes, exercises) VALUES (NEW.workout_id, COALESCE(NEW.notes, ''), '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workouts_update AFTER UPDATE OF notes ON workouts
+BEGIN
+    UPDATE workout_search SET notes = COALESCE(NEW.notes, '') WHERE rowid = NEW.workout_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workouts_delete AFTER DELETE ON workouts
+BEGIN
+    DELETE FROM workout_search WHERE rowid = OLD.workout_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workout_exercises_insert AFTER INSERT ON workout_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = NEW.workout_id), '')
+    WHERE rowid = NEW.workout_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workout_exercises_delete AFTER DELETE ON workout_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = OLD.workout_id), '')
+    WHERE rowid = OLD.workout_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_workout_exercises_update AFTER UPDATE OF workout_id, exercise_id ON workout_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = workout_search.rowid), '')
+    WHERE rowid IN (OLD.workout_id, NEW.workout_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS workout_search_allowed_exercises_update AFTER UPDATE OF name ON allowed_exercises
+BEGIN
+    UPDATE workout_search SET exercises = COALESCE((
+        SELECT group_concat(ae.name, ' ')
+        FROM workout_exercises we
+        JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+        WHERE we.workout_id = workout_search.rowid), '')
+    WHERE rowid IN (SELECT workout_id FROM workout_exercises WHERE exercise_id = NEW.exercise_id);
+END;
@@ -0,0 +1,5 @@
+DELETE FROM workouts WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_workouts_deleted_at;
+
+ALTER TABLE workouts DROP COLUMN IF EXISTS deleted_at;
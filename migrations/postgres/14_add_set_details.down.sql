@@ -0,0 +1,5 @@
+ALTER TABLE sets DROP COLUMN IF EXISTS tempo;
+ALTER TABLE sets DROP COLUMN IF EXISTS rest_seconds;
+ALTER TABLE sets DROP COLUMN IF EXISTS rir;
+ALTER TABLE sets DROP COLUMN IF EXISTS rpe;
+ALTER TABLE sets DROP COLUMN IF EXISTS set_type;
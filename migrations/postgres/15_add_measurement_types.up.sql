@@ -0,0 +1,13 @@
+-- Exercises say what their sets record. Sets of timed and distance exercises
+-- record a duration, and a distance, instead of repetitions.
+ALTER TABLE allowed_exercises ADD COLUMN IF NOT EXISTS measurement_type TEXT NOT NULL DEFAULT 'reps_weight'
+    CHECK (measurement_type IN ('reps_weight', 'reps', 'time', 'distance_time'));
+ALTER TABLE sets ADD COLUMN IF NOT EXISTS duration_seconds INTEGER;
+ALTER TABLE sets ADD COLUMN IF NOT EXISTS distance_meters DOUBLE PRECISION;
+
+INSERT INTO allowed_exercises (name, description, measurement_type)
+VALUES
+    ('Бег', 'Кардио на дистанцию', 'distance_time'),
+    ('Гребля', 'Кардио на гребном тренажёре', 'distance_time'),
+    ('Планка', 'Статическое упражнение для кора', 'time')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS body_weights;
+ALTER TABLE allowed_exercises DROP COLUMN IF EXISTS bodyweight;
+ALTER TABLE users DROP COLUMN IF EXISTS weight_unit;
@@ -0,0 +1,17 @@
+-- Weights are stored in kilograms and converted to each user's unit at the
+-- API. Sets of bodyweight exercises record the load added to, or with a
+-- negative weight taken off, the user's body weight, logged per day.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS weight_unit TEXT NOT NULL DEFAULT 'kg'
+    CHECK (weight_unit IN ('kg', 'lb'));
+ALTER TABLE allowed_exercises ADD COLUMN IF NOT EXISTS bodyweight BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE allowed_exercises SET bodyweight = TRUE WHERE name IN ('Подтягивания', 'Отжимания');
+
+CREATE TABLE IF NOT EXISTS body_weights (
+    body_weight_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    measured_on TEXT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL CHECK (weight > 0),
+    UNIQUE (user_id, measured_on),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- The global catalog has no room for custom exercises, so they go, together
+-- with the workout exercises and sets recorded with them.
+DELETE FROM workout_exercises
+WHERE exercise_id IN (SELECT exercise_id FROM allowed_exercises WHERE owner_id IS NOT NULL);
+DELETE FROM allowed_exercises WHERE owner_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_allowed_exercises_owner_name;
+DROP INDEX IF EXISTS idx_allowed_exercises_global_name;
+ALTER TABLE allowed_exercises DROP COLUMN IF EXISTS owner_id;
+ALTER TABLE allowed_exercises ADD CONSTRAINT allowed_exercises_name_key UNIQUE (name);
@@ -0,0 +1,8 @@
+-- Users can add their own exercises next to the global catalog, which keeps
+-- owner_id NULL. Names are unique per owner.
+ALTER TABLE allowed_exercises ADD COLUMN IF NOT EXISTS owner_id BIGINT
+    REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE allowed_exercises DROP CONSTRAINT IF EXISTS allowed_exercises_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_allowed_exercises_global_name ON allowed_exercises(name) WHERE owner_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_allowed_exercises_owner_name ON allowed_exercises(owner_id, name) WHERE owner_id IS NOT NULL;
@@ -0,0 +1,171 @@
+-- Normalized taxonomy of exercises: the muscles they work, as primary or
+-- secondary movers, the equipment they need and their movement pattern.
+-- Codes are stable identifiers for the API; names are for display.
+
+CREATE TABLE IF NOT EXISTS muscle_groups (
+    muscle_group_id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS equipment (
+    equipment_id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS movement_patterns (
+    movement_pattern_id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS exercise_muscles (
+    exercise_id BIGINT NOT NULL,
+    muscle_group_id BIGINT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('primary', 'secondary')),
+    PRIMARY KEY (exercise_id, muscle_group_id),
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id) ON DELETE CASCADE,
+    FOREIGN KEY (muscle_group_id) REFERENCES muscle_groups(muscle_group_id)
+);
+
+CREATE TABLE IF NOT EXISTS exercise_equipment (
+    exercise_id BIGINT NOT NULL,
+    equipment_id BIGINT NOT NULL,
+    PRIMARY KEY (exercise_id, equipment_id),
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment(equipment_id)
+);
+
+ALTER TABLE allowed_exercises ADD COLUMN IF NOT EXISTS movement_pattern_id BIGINT
+    REFERENCES movement_patterns(movement_pattern_id);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_muscles_muscle_group_id ON exercise_muscles(muscle_group_id);
+CREATE INDEX IF NOT EXISTS idx_exercise_equipment_equipment_id ON exercise_equipment(equipment_id);
+
+INSERT INTO muscle_groups (code, name)
+VALUES
+    ('chest', 'Грудные'),
+    ('shoulders', 'Плечи'),
+    ('triceps', 'Трицепс'),
+    ('biceps', 'Бицепс'),
+    ('forearms', 'Предплечья'),
+    ('lats', 'Широчайшие'),
+    ('upper_back', 'Верх спины'),
+    ('lower_back', 'Поясница'),
+    ('core', 'Кор'),
+    ('glutes', 'Ягодицы'),
+    ('quadriceps', 'Квадрицепсы'),
+    ('hamstrings', 'Бицепс бедра'),
+    ('calves', 'Икры')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO equipment (code, name)
+VALUES
+    ('none', 'Без оборудования'),
+    ('barbell', 'Штанга'),
+    ('dumbbell', 'Гантели'),
+    ('kettlebell', 'Гиря'),
+    ('bench', 'Скамья'),
+    ('pull_up_bar', 'Турник'),
+    ('machine', 'Тренажёр'),
+    ('cable', 'Блок'),
+    ('rowing_machine', 'Гребной тренажёр'),
+    ('band', 'Резинка')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO movement_patterns (code, name)
+VALUES
+    ('squat', 'Присед'),
+    ('hinge', 'Наклон в тазобедренных'),
+    ('lunge', 'Выпад'),
+    ('horizontal_push', 'Горизонтальный жим'),
+    ('vertical_push', 'Вертикальный жим'),
+    ('horizontal_pull', 'Горизонтальная тяга'),
+    ('vertical_pull', 'Вертикальная тяга'),
+    ('carry', 'Переноска'),
+    ('core', 'Стабилизация корпуса'),
+    ('cardio', 'Кардио')
+ON CONFLICT DO NOTHING;
+
+-- The global catalog is matched by name, as its IDs differ between
+-- databases.
+INSERT INTO exercise_muscles (exercise_id, muscle_group_id, role)
+WITH v(exercise, muscle, role) AS (VALUES
+    ('Приседания', 'quadriceps', 'primary'),
+    ('Приседания', 'glutes', 'primary'),
+    ('Приседания', 'hamstrings', 'secondary'),
+    ('Приседания', 'lower_back', 'secondary'),
+    ('Приседания', 'core', 'secondary'),
+    ('Жим лёжа', 'chest', 'primary'),
+    ('Жим лёжа', 'triceps', 'secondary'),
+    ('Жим лёжа', 'shoulders', 'secondary'),
+    ('Становая тяга', 'hamstrings', 'primary'),
+    ('Становая тяга', 'glutes', 'primary'),
+    ('Становая тяга', 'lower_back', 'primary'),
+    ('Становая тяга', 'quadriceps', 'secondary'),
+    ('Становая тяга', 'upper_back', 'secondary'),
+    ('Становая тяга', 'forearms', 'secondary'),
+    ('Подтягивания', 'lats', 'primary'),
+    ('Подтягивания', 'biceps', 'secondary'),
+    ('Подтягивания', 'upper_back', 'secondary'),
+    ('Подтягивания', 'forearms', 'secondary'),
+    ('Отжимания', 'chest', 'primary'),
+    ('Отжимания', 'triceps', 'secondary'),
+    ('Отжимания', 'shoulders', 'secondary'),
+    ('Отжимания', 'core', 'secondary'),
+    ('Бег', 'quadriceps', 'primary'),
+    ('Бег', 'hamstrings', 'primary'),
+    ('Бег', 'calves', 'primary'),
+    ('Бег', 'glutes', 'secondary'),
+    ('Бег', 'core', 'secondary'),
+    ('Гребля', 'lats', 'primary'),
+    ('Гребля', 'upper_back', 'primary'),
+    ('Гребля', 'quadriceps', 'secondary'),
+    ('Гребля', 'hamstrings', 'secondary'),
+    ('Гребля', 'biceps', 'secondary'),
+    ('Планка', 'core', 'primary'),
+    ('Планка', 'shoulders', 'secondary'),
+    ('Планка', 'glutes', 'secondary')
+)
+SELECT ae.exercise_id, mg.muscle_group_id, v.role
+FROM v
+JOIN allowed_exercises ae ON ae.name = v.exercise AND ae.owner_id IS NULL
+JOIN muscle_groups mg ON mg.code = v.muscle
+ON CONFLICT DO NOTHING;
+
+INSERT INTO exercise_equipment (exercise_id, equipment_id)
+WITH v(exercise, equipment) AS (VALUES
+    ('Приседания', 'barbell'),
+    ('Жим лёжа', 'barbell'),
+    ('Жим лёжа', 'bench'),
+    ('Становая тяга', 'barbell'),
+    ('Подтягивания', 'pull_up_bar'),
+    ('Отжимания', 'none'),
+    ('Бег', 'none'),
+    ('Гребля', 'rowing_machine'),
+    ('Планка', 'none')
+)
+SELECT ae.exercise_id, eq.equipment_id
+FROM v
+JOIN allowed_exercises ae ON ae.name = v.exercise AND ae.owner_id IS NULL
+JOIN equipment eq ON eq.code = v.equipment
+ON CONFLICT DO NOTHING;
+
+WITH v(exercise, pattern) AS (VALUES
+    ('Приседания', 'squat'),
+    ('Жим лёжа', 'horizontal_push'),
+    ('Становая тяга', 'hinge'),
+    ('Подтягивания', 'vertical_pull'),
+    ('Отжимания', 'horizontal_push'),
+    ('Бег', 'cardio'),
+    ('Гребля', 'cardio'),
+    ('Планка', 'core')
+)
+UPDATE allowed_exercises
+SET movement_pattern_id = (
+    SELECT mp.movement_pattern_id
+    FROM v
+    JOIN movement_patterns mp ON mp.code = v.pattern
+    WHERE v.exercise = allowed_exercises.name)
+WHERE owner_id IS NULL;
@@ -0,0 +1,30 @@
+-- Translations of exercise names and descriptions, keyed by locale. The
+-- names in allowed_exercises are Russian, the default locale, and stand in
+-- for any missing translation. Users may prefer a locale over the one their
+-- client asks for; NULL leaves it to the client.
+CREATE TABLE IF NOT EXISTS exercise_translations (
+    exercise_id BIGINT NOT NULL,
+    locale TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (exercise_id, locale),
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id) ON DELETE CASCADE
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT;
+
+INSERT INTO exercise_translations (exercise_id, locale, name, description)
+WITH v(exercise, name, description) AS (VALUES
+    ('Приседания', 'Squat', 'Exercise for the legs and glutes'),
+    ('Жим лёжа', 'Bench press', 'Strength exercise for the chest and triceps'),
+    ('Становая тяга', 'Deadlift', 'Compound exercise for the back and legs'),
+    ('Подтягивания', 'Pull-up', 'Exercise for the back and biceps'),
+    ('Отжимания', 'Push-up', 'Basic exercise for the chest, shoulders and triceps'),
+    ('Бег', 'Running', 'Distance cardio'),
+    ('Гребля', 'Rowing', 'Cardio on a rowing machine'),
+    ('Планка', 'Plank', 'Static core exercise')
+)
+SELECT ae.exercise_id, 'en', v.name, v.description
+FROM v
+JOIN allowed_exercises ae ON ae.name = v.exercise AND ae.owner_id IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS sets;
+DROP TABLE IF EXISTS workout_exercises;
+DROP TABLE IF EXISTS workouts;
+DROP TABLE IF EXISTS allowed_exercises;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS blacklisted_tokens;
@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS users (
+    user_id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS allowed_exercises (
+    exercise_id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS workouts (
+    workout_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    workout_date TEXT DEFAULT (to_char(CURRENT_DATE, 'YYYY-MM-DD')),
+    workout_start_time TEXT DEFAULT (to_char(LOCALTIME, 'HH24:MI:SS')),
+    workout_end_time TEXT,
+    notes TEXT,
+    photo TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workout_exercises (
+    workout_exercise_id BIGSERIAL PRIMARY KEY,
+    workout_id BIGINT NOT NULL,
+    exercise_id BIGINT NOT NULL,
+    FOREIGN KEY (workout_id) REFERENCES workouts(workout_id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id)
+);
+
+CREATE TABLE IF NOT EXISTS sets (
+    set_id BIGSERIAL PRIMARY KEY,
+    workout_exercise_id BIGINT NOT NULL,
+    repetitions INTEGER NOT NULL,
+    weight DOUBLE PRECISION NOT NULL,
+    FOREIGN KEY (workout_exercise_id) REFERENCES workout_exercises(workout_exercise_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS blacklisted_tokens (
+    token_id BIGSERIAL PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    expiration_time TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_workouts_user_id ON workouts(user_id);
+CREATE INDEX IF NOT EXISTS idx_workout_exercises_workout_id ON workout_exercises(workout_id);
+CREATE INDEX IF NOT EXISTS idx_sets_workout_exercise_id ON sets(workout_exercise_id);
+CREATE INDEX IF NOT EXISTS idx_blacklisted_tokens_expiration ON blacklisted_tokens(expiration_time);
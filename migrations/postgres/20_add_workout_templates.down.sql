@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS template_sets;
+DROP TABLE IF EXISTS template_exercises;
+DROP TABLE IF EXISTS workout_templates;
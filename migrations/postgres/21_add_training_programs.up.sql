@@ -0,0 +1,71 @@
+-- Template sets may give their load as a percentage of a training max
+-- instead of a weight. The training maxes come with an enrollment in a
+-- program, so the same template follows the lifter from cycle to cycle.
+ALTER TABLE template_sets ADD COLUMN IF NOT EXISTS percent_of_tm DOUBLE PRECISION CHECK (percent_of_tm > 0);
+
+-- A program runs for a number of weeks; each of its days trains a template
+-- on a day of the week, 1 to 7 counted from the day the program starts.
+CREATE TABLE IF NOT EXISTS programs (
+    program_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    weeks INTEGER NOT NULL CHECK (weeks BETWEEN 1 AND 52),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    program_day_id BIGSERIAL PRIMARY KEY,
+    program_id BIGINT NOT NULL,
+    week INTEGER NOT NULL CHECK (week >= 1),
+    day INTEGER NOT NULL CHECK (day BETWEEN 1 AND 7),
+    template_id BIGINT NOT NULL,
+    UNIQUE (program_id, week, day),
+    FOREIGN KEY (program_id) REFERENCES programs(program_id) ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES workout_templates(template_id)
+);
+
+-- Enrolling in a program plans a workout for each of its days. The plans
+-- copy the week, day and template so that later edits to the program only
+-- affect new enrollments. A plan is done once a workout is logged on its
+-- date.
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    enrollment_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    program_id BIGINT NOT NULL,
+    start_date TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (program_id) REFERENCES programs(program_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS training_maxes (
+    enrollment_id BIGINT NOT NULL,
+    exercise_id BIGINT NOT NULL,
+    weight DOUBLE PRECISION NOT NULL CHECK (weight > 0),
+    PRIMARY KEY (enrollment_id, exercise_id),
+    FOREIGN KEY (enrollment_id) REFERENCES program_enrollments(enrollment_id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES allowed_exercises(exercise_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS planned_workouts (
+    planned_workout_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    enrollment_id BIGINT NOT NULL,
+    week INTEGER NOT NULL,
+    day INTEGER NOT NULL,
+    template_id BIGINT NOT NULL,
+    planned_date TEXT NOT NULL,
+    workout_id BIGINT,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (enrollment_id) REFERENCES program_enrollments(enrollment_id) ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES workout_templates(template_id),
+    FOREIGN KEY (workout_id) REFERENCES workouts(workout_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_days_template_id ON program_days(template_id);
+CREATE INDEX IF NOT EXISTS idx_planned_workouts_user_date ON planned_workouts(user_id, planned_date);
+CREATE INDEX IF NOT EXISTS idx_planned_workouts_template_id ON planned_workouts(template_id);
+CREATE INDEX IF NOT EXISTS idx_planned_workouts_workout_id ON planned_workouts(workout_id);
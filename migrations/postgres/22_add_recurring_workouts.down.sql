@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS recurrence_exceptions;
+DROP TABLE IF EXISTS recurring_workouts;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS workout_search_allowed_exercises ON allowed_exercises;
+DROP TRIGGER IF EXISTS workout_search_workout_exercises ON workout_exercises;
+DROP TRIGGER IF EXISTS workout_search_workouts ON workouts;
+DROP FUNCTION IF EXISTS workout_search_allowed_exercises_trigger();
+DROP FUNCTION IF EXISTS workout_search_workout_exercises_trigger();
+DROP FUNCTION IF EXISTS workout_search_workouts_trigger();
+DROP FUNCTION IF EXISTS workout_search_refresh(BIGINT);
+DROP FUNCTION IF EXISTS workout_search_document(BIGINT);
+DROP TABLE IF EXISTS workout_search;
@@ -0,0 +1,75 @@
+-- Full-text index over workout notes and the names of the exercises in each
+-- workout, the counterpart of the SQLite FTS5 table of migration 12. The
+-- document matches what search used to compute for every row. Triggers below
+-- keep it in sync; soft-deleted workouts stay indexed and are filtered out at
+-- query time. Encrypted notes cannot be searched and are indexed as empty.
+CREATE TABLE IF NOT EXISTS workout_search (
+    workout_id BIGINT PRIMARY KEY,
+    document TSVECTOR NOT NULL,
+    FOREIGN KEY (workout_id) REFERENCES workouts(workout_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS workout_search_document_idx ON workout_search USING GIN (document);
+
+CREATE OR REPLACE FUNCTION workout_search_document(id BIGINT) RETURNS TSVECTOR AS $$
+    SELECT to_tsvector('simple',
+        COALESCE((SELECT CASE WHEN w.notes LIKE 'enc:v1:%' THEN '' ELSE COALESCE(w.notes, '') END
+                  FROM workouts w WHERE w.workout_id = id), '')
+        || ' ' ||
+        COALESCE((SELECT string_agg(ae.name, ' ' ORDER BY we.workout_exercise_id)
+                  FROM workout_exercises we
+                  JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+                  WHERE we.workout_id = id), ''))
+$$ LANGUAGE SQL STABLE;
+
+-- workout_search_refresh recomputes the document of a workout. A workout
+-- being deleted has no document; its row goes with it.
+CREATE OR REPLACE FUNCTION workout_search_refresh(id BIGINT) RETURNS VOID AS $$
+    INSERT INTO workout_search (workout_id, document)
+    SELECT id, workout_search_document(id)
+    WHERE EXISTS (SELECT 1 FROM workouts WHERE workout_id = id)
+    ON CONFLICT (workout_id) DO UPDATE SET document = excluded.document
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION workout_search_workouts_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM workout_search_refresh(NEW.workout_id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS workout_search_workouts ON workouts;
+CREATE TRIGGER workout_search_workouts AFTER INSERT OR UPDATE OF notes ON workouts
+    FOR EACH ROW EXECUTE FUNCTION workout_search_workouts_trigger();
+
+CREATE OR REPLACE FUNCTION workout_search_workout_exercises_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM workout_search_refresh(OLD.workout_id);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM workout_search_refresh(NEW.workout_id);
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS workout_search_workout_exercises ON workout_exercises;
+CREATE TRIGGER workout_search_workout_exercises AFTER INSERT OR DELETE OR UPDATE OF workout_id, exercise_id ON workout_exercises
+    FOR EACH ROW EXECUTE FUNCTION workout_search_workout_exercises_trigger();
+
+CREATE OR REPLACE FUNCTION workout_search_allowed_exercises_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM workout_search_refresh(workout_id)
+    FROM (SELECT DISTINCT workout_id FROM workout_exercises WHERE exercise_id = NEW.exercise_id) affected;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS workout_search_allowed_exercises ON allowed_exercises;
+CREATE TRIGGER workout_search_allowed_exercises AFTER UPDATE OF name ON allowed_exercises
+    FOR EACH ROW EXECUTE FUNCTION workout_search_allowed_exercises_trigger();
+
+INSERT INTO workout_search (workout_id, document)
+SELECT workout_id, workout_search_document(workout_id) FROM workouts
+ON CONFLICT (workout_id) DO NOTHING;
@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION workout_search_document(id BIGINT) RETURNS TSVECTOR AS $$
+    SELECT to_tsvector('simple',
+        COALESCE((SELECT CASE WHEN w.notes LIKE 'enc:v1:%' THEN '' ELSE COALESCE(w.notes, '') END
+                  FROM workouts w WHERE w.workout_id = id), '')
+        || ' ' ||
+        COALESCE((SELECT string_agg(ae.name, ' ' ORDER BY we.workout_exercise_id)
+                  FROM workout_exercises we
+                  JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+                  WHERE we.workout_id = id), ''))
+$$ LANGUAGE SQL STABLE;
@@ -0,0 +1,12 @@
+-- Sealed values now carry their format version after "enc:v", so the search
+-- document treats notes of any version as encrypted and indexes them as empty.
+CREATE OR REPLACE FUNCTION workout_search_document(id BIGINT) RETURNS TSVECTOR AS $$
+    SELECT to_tsvector('simple',
+        COALESCE((SELECT CASE WHEN w.notes ~ '^enc:v[0-9]+:' THEN '' ELSE COALESCE(w.notes, '') END
+                  FROM workouts w WHERE w.workout_id = id), '')
+        || ' ' ||
+        COALESCE((SELECT string_agg(ae.name, ' ' ORDER BY we.workout_exercise_id)
+                  FROM workout_exercises we
+                  JOIN allowed_exercises ae ON ae.exercise_id = we.exercise_id
+                  WHERE we.workout_id = id), ''))
+$$ LANGUAGE SQL STABLE;
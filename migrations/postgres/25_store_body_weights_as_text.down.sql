@@ -0,0 +1,4 @@
+-- Encrypted weights cannot be turned back into numbers; run it only after
+-- every weight is stored in plain text.
+ALTER TABLE body_weights ALTER COLUMN weight TYPE DOUBLE PRECISION USING weight::DOUBLE PRECISION;
+ALTER TABLE body_weights ADD CONSTRAINT body_weights_weight_check CHECK (weight > 0);
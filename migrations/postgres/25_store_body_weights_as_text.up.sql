@@ -0,0 +1,4 @@
+-- Body weights may be encrypted at rest, so a weight is stored as text: the
+-- number in kilograms, or the sealed number.
+ALTER TABLE body_weights DROP CONSTRAINT IF EXISTS body_weights_weight_check;
+ALTER TABLE body_weights ALTER COLUMN weight TYPE TEXT USING weight::TEXT;
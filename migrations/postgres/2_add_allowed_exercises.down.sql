@@ -0,0 +1,2 @@
+DELETE FROM allowed_exercises 
+WHERE name IN ('Приседания', 'Жим лёжа', 'Становая тяга', 'Подтягивания', 'Отжимания');
\ No newline at end of file
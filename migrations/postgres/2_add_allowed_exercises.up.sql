@@ -0,0 +1,7 @@
+INSERT INTO allowed_exercises (name, description) 
+VALUES 
+    ('Приседания', 'Упражнение для ног и ягодиц'),
+    ('Жим лёжа', 'Силовое упражнение для груди и трицепсов'),
+    ('Становая тяга', 'Комплексное упражнение для спины и ног'),
+    ('Подтягивания', 'Упражнение для спины и бицепсов'),
+    ('Отжимания', 'Базовое упражнение для груди, плеч и трицепсов');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_workouts_user_id_workout_date;